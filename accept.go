@@ -0,0 +1,76 @@
+package htmx
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PrefersHTML reports whether an HTML representation should be used to
+// respond to r, as opposed to e.g. JSON.
+//
+// This is always the case for htmx requests.
+// For other requests, the Accept header is consulted, and PrefersHTML
+// reports true, if the quality of text/html is at least as high as that of
+// application/json.
+// If multiple media ranges match a type, e.g. text/html and text/*, the
+// quality of the most specific one is used.
+// A missing Accept header is treated like */*, and therefore reports true.
+func PrefersHTML(r *http.Request) bool {
+	if IsHTMX(r) {
+		return true
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+
+	htmlQ := acceptQuality(accept, "text/html")
+	return htmlQ > 0 && htmlQ >= acceptQuality(accept, "application/json")
+}
+
+// acceptQuality returns the quality with which the passed media type is
+// accepted by the passed Accept header value.
+//
+// If multiple ranges match, the quality of the most specific one is used.
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	var q float64
+	specificity := -1
+	for _, rng := range strings.Split(accept, ",") {
+		rngType, params, err := mime.ParseMediaType(strings.TrimSpace(rng))
+		if err != nil {
+			continue
+		}
+
+		var s int
+		switch rngType {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s <= specificity {
+			continue
+		}
+
+		specificity = s
+		q = 1
+		if qStr, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(qStr, 64)
+			if err != nil {
+				q = 0
+			}
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,52 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrefersHTML(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		htmx   bool
+		accept string
+		expect bool
+	}{
+		{name: "htmx request", htmx: true, accept: "application/json", expect: true},
+		{name: "no accept", expect: true},
+		{name: "any", accept: "*/*", expect: true},
+		{name: "html", accept: "text/html", expect: true},
+		{name: "json", accept: "application/json", expect: false},
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", expect: true},
+		{name: "json preferred", accept: "application/json, text/html;q=0.5", expect: false},
+		{name: "html preferred", accept: "application/json;q=0.5, text/html", expect: true},
+		{name: "equal quality", accept: "application/json, text/html", expect: true},
+		{name: "specific over range", accept: "text/*;q=0.1, text/html, application/json;q=0.5", expect: true},
+		{name: "range over any", accept: "text/*;q=0.1, */*", expect: false},
+		{name: "html excluded", accept: "text/html;q=0, */*", expect: false},
+		{name: "invalid quality", accept: "text/html;q=abc, application/json", expect: false},
+		{name: "other type", accept: "image/png", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.htmx {
+				r.Header.Set("HX-Request", "true")
+			}
+			if c.accept != "" {
+				r.Header.Set("Accept", c.accept)
+			}
+
+			if actual := PrefersHTML(r); actual != c.expect {
+				t.Errorf("PrefersHTML() with Accept %q = %t, expected %t", c.accept, actual, c.expect)
+			}
+		})
+	}
+}
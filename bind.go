@@ -0,0 +1,118 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// BindQuery decodes the query parameters of r into a new T, which must be a
+// struct.
+//
+// This is useful for boosted GET forms, which send their values as query
+// parameters.
+//
+// The name of the query parameter is taken from the field's form tag, e.g.
+// `form:"name"`, or, if there is none, from the name of the field.
+// Fields tagged with `form:"-"` and unexported fields are ignored.
+//
+// Supported field types are strings, bools, ints, uints, floats, and slices
+// of those.
+// Bools additionally accept "on", which is sent by checked checkboxes
+// without a value attribute.
+// For non-slice fields, only the first value of a parameter is used.
+// Fields whose parameter is missing are left as is.
+func BindQuery[T any](r *http.Request) (T, error) {
+	var t T
+	err := decodeValues(r.URL.Query(), &t)
+	return t, err
+}
+
+// decodeValues decodes vals into the struct pointed to by dst.
+func decodeValues(vals url.Values, dst any) error {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Struct {
+		return errors.New("htmx: can only decode into structs")
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+
+		name := ft.Tag.Get("form")
+		if name == "-" {
+			continue
+		} else if name == "" {
+			name = ft.Name
+		}
+
+		fieldVals, ok := vals[name]
+		if !ok || len(fieldVals) == 0 {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() != reflect.Slice {
+			if err := decodeValue(fieldVals[0], fv); err != nil {
+				return fmt.Errorf("htmx: %s: %w", name, err)
+			}
+			continue
+		}
+
+		s := reflect.MakeSlice(fv.Type(), len(fieldVals), len(fieldVals))
+		for j, val := range fieldVals {
+			if err := decodeValue(val, s.Index(j)); err != nil {
+				return fmt.Errorf("htmx: %s[%d]: %w", name, j, err)
+			}
+		}
+		fv.Set(s)
+	}
+
+	return nil
+}
+
+func decodeValue(val string, dst reflect.Value) error {
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(val)
+	case reflect.Bool:
+		if val == "on" { // checked checkboxes without value attribute
+			dst.SetBool(true)
+			return nil
+		}
+
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", dst.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type bindTarget struct {
+	Name     string   `form:"name"`
+	Age      int      `form:"age"`
+	Score    float64  `form:"score"`
+	Count    uint8    `form:"count"`
+	Active   bool     `form:"active"`
+	Tags     []string `form:"tag"`
+	IDs      []int    `form:"id"`
+	Untagged string
+	Ignored  string `form:"-"`
+	ignored  string
+}
+
+func TestBindQuery(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		query  string
+		expect bindTarget
+	}{
+		{name: "empty", query: "", expect: bindTarget{}},
+		{
+			name:  "scalars",
+			query: "name=abc&age=12&score=1.5&count=3&active=true",
+			expect: bindTarget{
+				Name:   "abc",
+				Age:    12,
+				Score:  1.5,
+				Count:  3,
+				Active: true,
+			},
+		},
+		{name: "checked checkbox", query: "active=on", expect: bindTarget{Active: true}},
+		{name: "unchecked checkbox", query: "name=abc", expect: bindTarget{Name: "abc"}},
+		{name: "first value of scalar", query: "name=abc&name=def", expect: bindTarget{Name: "abc"}},
+		{
+			name:   "slices",
+			query:  "tag=a&tag=b&id=1&id=2&id=3",
+			expect: bindTarget{Tags: []string{"a", "b"}, IDs: []int{1, 2, 3}},
+		},
+		{name: "untagged", query: "Untagged=abc", expect: bindTarget{Untagged: "abc"}},
+		{name: "ignored", query: "Ignored=abc&ignored=def&-=ghi", expect: bindTarget{}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/?"+c.query, nil)
+
+				actual, err := BindQuery[bindTarget](r)
+				if err != nil {
+					t.Fatalf("BindQuery returned error: %v", err)
+				}
+
+				if !reflect.DeepEqual(actual, c.expect) {
+					t.Errorf("BindQuery() = %+v, expected %+v", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name  string
+		query string
+	}{
+		{name: "invalid int", query: "age=abc"},
+		{name: "int out of range", query: "count=256"},
+		{name: "invalid bool", query: "active=abc"},
+		{name: "invalid float", query: "score=abc"},
+		{name: "invalid slice element", query: "id=1&id=abc"},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/?"+c.query, nil)
+				if _, err := BindQuery[bindTarget](r); err == nil {
+					t.Error("expected BindQuery to return an error")
+				}
+			})
+		}
+	})
+
+	t.Run("non-struct", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/?a=b", nil)
+		if _, err := BindQuery[string](r); err == nil {
+			t.Error("expected BindQuery to return an error")
+		}
+	})
+}
@@ -0,0 +1,16 @@
+// Package htmx provides helpers for reading and setting htmx headers.
+//
+// The request headers sent by htmx are read using [Request].
+// Response headers are set using the setters, such as [Retarget] and
+// [Trigger], and are written by the middleware created using
+// [NewMiddleware].
+//
+// # Event Registry
+//
+// The names of the events triggered by an application can optionally be
+// registered, to catch typos using [MustEvent].
+// Events are registered either in bulk using [DefineEvents], or one at a
+// time using [NewEvent].
+// Both register events in the same, global registry, which can be listed
+// using [RegisteredEvents].
+package htmx
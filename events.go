@@ -0,0 +1,373 @@
+package htmx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// events is the registry of events, shared by [DefineEvents] and [NewEvent].
+var (
+	eventsMu sync.RWMutex
+	events   = make(map[Event]struct{})
+)
+
+// DefineEvents registers the passed event names, and returns a map of the
+// names to their events.
+//
+// Registering the events used by an application in one place allows
+// [MustEvent] to catch typos in event names:
+//
+//	var events = htmx.DefineEvents("itemDeleted", "cartUpdated")
+//
+//	htmx.Trigger(r, htmx.MustEvent("itemDeleted"), nil)
+//
+// DefineEvents and [NewEvent] register events in the same registry, so
+// they may be used together.
+// Using the registry is entirely optional.
+func DefineEvents(names ...string) map[string]Event {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+
+	m := make(map[string]Event, len(names))
+	for _, name := range names {
+		events[name] = struct{}{}
+		m[name] = name
+	}
+
+	return m
+}
+
+// NewEvent registers the event with the passed name, and returns it.
+//
+// It allows declaring an application's events once, and using the variables
+// instead of string literals:
+//
+//	var ItemDeleted = htmx.NewEvent("itemDeleted")
+//
+//	htmx.Trigger(r, ItemDeleted, nil)
+//
+// The event is registered in the same registry as the events registered
+// using [DefineEvents], so that [MustEvent] accepts it as well.
+func NewEvent(name string) Event {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+
+	events[name] = struct{}{}
+	return name
+}
+
+// RegisteredEvents returns all events registered using [DefineEvents] and
+// [NewEvent], sorted by name.
+//
+// This is useful for documenting the events of an application, or for
+// testing that client-side listeners exist for all of them.
+func RegisteredEvents() []Event {
+	eventsMu.RLock()
+	defer eventsMu.RUnlock()
+
+	es := make([]Event, 0, len(events))
+	for e := range events {
+		es = append(es, e)
+	}
+	sort.Strings(es)
+
+	return es
+}
+
+// MustEvent returns the event with the passed name.
+//
+// It panics, if no event with that name was registered using
+// [DefineEvents] or [NewEvent].
+func MustEvent(name string) Event {
+	eventsMu.RLock()
+	defer eventsMu.RUnlock()
+
+	if _, ok := events[name]; !ok {
+		panic(fmt.Sprintf("htmx: event %q is not defined", name))
+	}
+
+	return name
+}
+
+// DelayKey is the key of the delay, in milliseconds, in the detail of events
+// triggered by [TriggerDelayed].
+var DelayKey = "delay"
+
+// TriggerDelayed triggers the passed event after the settling step, and
+// includes the passed delay in the event's detail, so that a client-side
+// listener can delay its handling of the event.
+//
+// The detail of the event is a JSON object containing the delay in
+// milliseconds under [DelayKey], and data under "data":
+//
+//	{"delay": 3000, "data": {"message": "Saved!"}}
+//
+// Since htmx doesn't support delaying events itself, this requires a
+// client-side listener that calls setTimeout, e.g. to implement flash
+// messages that dismiss themselves:
+//
+//	document.body.addEventListener("flash", (evt) => {
+//	    const flash = showFlash(evt.detail.data.message);
+//	    setTimeout(() => flash.remove(), evt.detail.delay);
+//	});
+//
+// If a there already is an after-settle trigger for that event, it will be
+// overwritten.
+//
+// An error will only be returned if data can't be marshalled to json.
+func TriggerDelayed(r *http.Request, name Event, delay time.Duration, data any) error {
+	jsonData, err := json.Marshal(map[string]any{
+		DelayKey: delay.Milliseconds(),
+		"data":   data,
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp := attachedResponse(r); resp != nil {
+		resp.TriggerAfterSettle[name] = jsonData
+	}
+	return nil
+}
+
+// ErrorEvent is the event triggered by [RespondError] and [TriggerError].
+//
+// Change it, if your application uses a different event name.
+var ErrorEvent Event = "showError"
+
+// TriggerError triggers [ErrorEvent] with {"error": message} as detail, e.g.
+// to display an error message when a handler fails.
+//
+// Previous values are overwritten.
+func TriggerError(r *http.Request, message string) {
+	data, _ := json.Marshal(map[string]string{"error": message}) // strings never fail
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[ErrorEvent] = data
+	}
+}
+
+// TitleEvent is the event triggered by [SetTitle].
+var TitleEvent Event = "setTitle"
+
+// SetTitle updates the title of the document.
+//
+// When swapping fragments, htmx only updates the title, if the response
+// contains a <title> element.
+// Hence, there are two ways to update the title:
+//
+// The first is to simply render a <title> element as part of the fragment,
+// which requires no client-side code.
+// For the title to also be used when restoring history, this should be
+// combined with HX-Push-Url.
+//
+// The second, implemented by SetTitle, is to trigger [TitleEvent] with the
+// title in the event's detail, e.g. {"title": "Inbox (3)"}, which requires a
+// client-side listener:
+//
+//	document.body.addEventListener("setTitle", (evt) => {
+//	    document.title = evt.detail.title;
+//	});
+//
+// The latter is useful if the title changes without a navigation, or if
+// the fragment can't include a <title> element.
+//
+// Previous values are overwritten.
+func SetTitle(r *http.Request, title string) {
+	data, _ := json.Marshal(map[string]string{"title": title}) // strings never fail
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[TitleEvent] = data
+	}
+}
+
+// StatusStopPolling is the status code that tells htmx to stop polling.
+//
+// See: https://htmx.org/docs/#load_polling
+const StatusStopPolling = 286
+
+// PollIntervalEvent is the event triggered by [SetPollInterval].
+var PollIntervalEvent Event = "setPollInterval"
+
+// SetPollInterval tells the client to change the interval at which it polls.
+//
+// It triggers [PollIntervalEvent] with the interval in milliseconds in the
+// event's detail, e.g. {"interval": 5000}.
+// Since htmx doesn't support changing the interval of a polling element
+// itself, this requires a client-side listener, that updates hx-trigger of
+// the polling element and processes it again:
+//
+//	document.body.addEventListener("setPollInterval", (evt) => {
+//	    const elt = evt.target;
+//	    elt.setAttribute("hx-trigger", `every ${evt.detail.interval}ms`);
+//	    htmx.process(elt);
+//	});
+//
+// If d is zero or negative, SetPollInterval calls [StopPolling] instead.
+//
+// Previous values are overwritten.
+func SetPollInterval(r *http.Request, d time.Duration) {
+	if d <= 0 {
+		StopPolling(r)
+		return
+	}
+
+	data, _ := json.Marshal(map[string]int64{"interval": d.Milliseconds()}) // ints never fail
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[PollIntervalEvent] = data
+	}
+}
+
+// StopPolling tells the client to stop polling, by setting the status of the
+// response to [StatusStopPolling] using [SetStatus].
+func StopPolling(r *http.Request) {
+	SetStatus(r, StatusStopPolling)
+}
+
+// ScrollIntoViewEvent is the event triggered by [ScrollIntoView].
+var ScrollIntoViewEvent Event = "scrollIntoView"
+
+type (
+	// ScrollOptions are the options used to scroll an element into view.
+	//
+	// They mirror the options of Element.scrollIntoView.
+	//
+	// See: https://developer.mozilla.org/docs/Web/API/Element/scrollIntoView
+	ScrollOptions struct {
+		// Behavior determines whether scrolling is animated.
+		Behavior ScrollBehavior `json:"behavior,omitempty"`
+		// Block is the vertical alignment of the element.
+		Block ScrollAlignment `json:"block,omitempty"`
+		// Inline is the horizontal alignment of the element.
+		Inline ScrollAlignment `json:"inline,omitempty"`
+	}
+
+	// ScrollBehavior determines whether scrolling is animated.
+	ScrollBehavior string
+	// ScrollAlignment is the alignment of an element scrolled into view.
+	ScrollAlignment string
+)
+
+const (
+	ScrollBehaviorSmooth  ScrollBehavior = "smooth"
+	ScrollBehaviorInstant ScrollBehavior = "instant"
+	ScrollBehaviorAuto    ScrollBehavior = "auto"
+)
+
+const (
+	ScrollAlignStart   ScrollAlignment = "start"
+	ScrollAlignCenter  ScrollAlignment = "center"
+	ScrollAlignEnd     ScrollAlignment = "end"
+	ScrollAlignNearest ScrollAlignment = "nearest"
+)
+
+// ScrollIntoView scrolls the element matching the passed selector into view,
+// after the settling step.
+//
+// It triggers [ScrollIntoViewEvent] with the selector and the options in the
+// event's detail, e.g. {"selector": "#item-5", "behavior": "smooth"}, which
+// requires a client-side listener:
+//
+//	document.body.addEventListener("scrollIntoView", (evt) => {
+//	    const { selector, ...opts } = evt.detail;
+//	    document.querySelector(selector)?.scrollIntoView(opts);
+//	});
+//
+// Previous values are overwritten.
+func ScrollIntoView(r *http.Request, sel Selector, opts ScrollOptions) {
+	data, _ := json.Marshal(struct { // only strings, never fails
+		Selector Selector `json:"selector"`
+		ScrollOptions
+	}{Selector: sel, ScrollOptions: opts})
+	if resp := attachedResponse(r); resp != nil {
+		resp.TriggerAfterSettle[ScrollIntoViewEvent] = data
+	}
+}
+
+// FocusEvent is the event triggered by [FocusAfterSwap].
+var FocusEvent Event = "focusElement"
+
+// FocusAfterSwap focuses the element matching the passed selector after the
+// swap step, e.g. the first field with an error.
+//
+// It triggers [FocusEvent] after the swap with the selector in the event's
+// detail, e.g. {"selector": "#email"}, which requires a client-side
+// listener:
+//
+//	document.body.addEventListener("focusElement", (evt) => {
+//	    document.querySelector(evt.detail.selector)?.focus();
+//	});
+//
+// Previous values are overwritten.
+func FocusAfterSwap(r *http.Request, sel Selector) {
+	data, _ := json.Marshal(map[string]string{"selector": sel}) // strings never fail
+	if resp := attachedResponse(r); resp != nil {
+		resp.TriggerAfterSwap[FocusEvent] = data
+	}
+}
+
+// RedirectEvent is the event triggered by [RedirectAfter].
+var RedirectEvent Event = "redirectAfter"
+
+// RedirectAfter redirects the client to u after the passed delay, e.g. to
+// show a success message before navigating away.
+//
+// Since HX-Redirect redirects immediately, RedirectAfter triggers
+// [RedirectEvent] instead, with the URL and the delay in milliseconds, under
+// [DelayKey], in the event's detail, e.g.
+// {"url": "/orders/5", "delay": 2000}.
+// This requires a client-side listener:
+//
+//	document.body.addEventListener("redirectAfter", (evt) => {
+//	    setTimeout(() => window.location.href = evt.detail.url, evt.detail.delay);
+//	});
+//
+// Combine it with a trigger of your own to display the message.
+//
+// Previous values are overwritten.
+func RedirectAfter(r *http.Request, u URL, delay time.Duration) {
+	data, _ := json.Marshal(map[string]any{ // strings and ints never fail
+		"url":    u,
+		DelayKey: delay.Milliseconds(),
+	})
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[RedirectEvent] = data
+	}
+}
+
+// UpdateCounter changes the client-side counter triggered by the passed
+// event, e.g. a badge displaying the number of unread messages, by delta.
+//
+// It triggers the event with {"delta": delta} as detail.
+// Together with [SetCounter], this allows a single client-side listener to
+// handle all counters:
+//
+//	document.body.addEventListener("unreadCount", (evt) => {
+//	    const badge = document.getElementById("unread-count");
+//	    const count = evt.detail.value ?? (parseInt(badge.textContent) + evt.detail.delta);
+//	    badge.textContent = count;
+//	});
+//
+// Previous values are overwritten.
+func UpdateCounter(r *http.Request, name Event, delta int) {
+	data, _ := json.Marshal(map[string]int{"delta": delta}) // ints never fail
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[name] = data
+	}
+}
+
+// SetCounter sets the client-side counter triggered by the passed event to
+// value.
+//
+// It triggers the event with {"value": value} as detail.
+// See [UpdateCounter] for an example client-side listener.
+//
+// Previous values are overwritten.
+func SetCounter(r *http.Request, name Event, value int) {
+	data, _ := json.Marshal(map[string]int{"value": value}) // ints never fail
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[name] = data
+	}
+}
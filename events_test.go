@@ -0,0 +1,420 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDefineEvents(t *testing.T) {
+	t.Parallel()
+
+	actual := DefineEvents("defineEventsA", "defineEventsB")
+	expect := map[string]Event{"defineEventsA": "defineEventsA", "defineEventsB": "defineEventsB"}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Errorf("DefineEvents() = %v, expected %v", actual, expect)
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	t.Parallel()
+
+	if actual := NewEvent("newEventA"); actual != "newEventA" {
+		t.Errorf("NewEvent() = %q, expected %q", actual, "newEventA")
+	}
+}
+
+func TestRegisteredEvents(t *testing.T) {
+	t.Parallel()
+
+	DefineEvents("registeredEventsB")
+	NewEvent("registeredEventsA")
+
+	actual := RegisteredEvents()
+	if !sort.StringsAreSorted(actual) {
+		t.Errorf("RegisteredEvents() = %q, expected it to be sorted", actual)
+	}
+
+	for _, e := range []Event{"registeredEventsA", "registeredEventsB"} {
+		if !slices.Contains(actual, e) {
+			t.Errorf("RegisteredEvents() = %q, expected it to contain %q", actual, e)
+		}
+	}
+}
+
+func TestMustEvent(t *testing.T) {
+	t.Parallel()
+
+	DefineEvents("mustEventDefined")
+	NewEvent("mustEventNew")
+
+	testCases := []struct {
+		name        string
+		expectPanic bool
+	}{
+		{name: "mustEventDefined", expectPanic: false},
+		{name: "mustEventNew", expectPanic: false},
+		{name: "mustEventUnknown", expectPanic: true},
+		{name: "mustEventDefnied", expectPanic: true}, // typo
+		{name: "MustEventDefined", expectPanic: true}, // case mismatch
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if r := recover(); (r != nil) != c.expectPanic {
+					t.Errorf("recovered %v, expected panic: %t", r, c.expectPanic)
+				}
+			}()
+
+			if actual := MustEvent(c.name); actual != c.name {
+				t.Errorf("MustEvent() = %q, expected %q", actual, c.name)
+			}
+		})
+	}
+}
+
+func TestTriggerDelayed(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		delay     time.Duration
+		data      any
+		expect    string
+		expectErr bool
+	}{
+		{name: "no data", delay: 3 * time.Second, expect: `{"flash":{"data":null,"delay":3000}}`},
+		{
+			name:   "data",
+			delay:  1500 * time.Millisecond,
+			data:   map[string]string{"message": "Saved!"},
+			expect: `{"flash":{"data":{"message":"Saved!"},"delay":1500}}`,
+		},
+		{name: "invalid data", delay: time.Second, data: func() {}, expectErr: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			if err := TriggerDelayed(r, "flash", c.delay, c.data); (err != nil) != c.expectErr {
+				t.Fatalf("TriggerDelayed() = %v, expected error: %t", err, c.expectErr)
+			}
+
+			if actual := headers(r).Get("HX-Trigger-After-Settle"); actual != c.expect {
+				t.Errorf("HX-Trigger-After-Settle = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSetTitle(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		titles []string
+		expect string
+	}{
+		{name: "title", titles: []string{"Inbox (3)"}, expect: `{"setTitle":{"title":"Inbox (3)"}}`},
+		{name: "escaped", titles: []string{`"A" & B`}, expect: `{"setTitle":{"title":"\"A\" \u0026 B"}}`},
+		{name: "overwrite", titles: []string{"a", "b"}, expect: `{"setTitle":{"title":"b"}}`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			for _, title := range c.titles {
+				SetTitle(r, title)
+			}
+
+			if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSetPollInterval(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		d             time.Duration
+		expectTrigger string
+		expectStatus  int
+	}{
+		{
+			name:          "set",
+			d:             5 * time.Second,
+			expectTrigger: `{"setPollInterval":{"interval":5000}}`,
+			expectStatus:  http.StatusOK,
+		},
+		{
+			name:          "sub-millisecond",
+			d:             1500 * time.Microsecond,
+			expectTrigger: `{"setPollInterval":{"interval":1}}`,
+			expectStatus:  http.StatusOK,
+		},
+		{name: "zero", d: 0, expectStatus: StatusStopPolling},
+		{name: "negative", d: -time.Second, expectStatus: StatusStopPolling},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+				SetPollInterval(r, c.d)
+				_, _ = w.Write([]byte("abc"))
+			})
+
+			if actual := rec.Header().Get("HX-Trigger"); actual != c.expectTrigger {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expectTrigger)
+			}
+			if rec.Code != c.expectStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, c.expectStatus)
+			}
+		})
+	}
+}
+
+func TestStopPolling(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+		StopPolling(r)
+		_, _ = w.Write([]byte("abc"))
+	})
+
+	if rec.Code != StatusStopPolling {
+		t.Errorf("status = %d, expected %d", rec.Code, StatusStopPolling)
+	}
+	if actual := rec.Body.String(); actual != "abc" {
+		t.Errorf("body = %q, expected %q", actual, "abc")
+	}
+}
+
+func TestScrollIntoView(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		sel    Selector
+		opts   ScrollOptions
+		expect string
+	}{
+		{
+			name:   "no options",
+			sel:    "#item-5",
+			expect: `{"scrollIntoView":{"selector":"#item-5"}}`,
+		},
+		{
+			name:   "behavior",
+			sel:    "#item-5",
+			opts:   ScrollOptions{Behavior: ScrollBehaviorSmooth},
+			expect: `{"scrollIntoView":{"selector":"#item-5","behavior":"smooth"}}`,
+		},
+		{
+			name: "all options",
+			sel:  "#item-5",
+			opts: ScrollOptions{Behavior: ScrollBehaviorAuto, Block: ScrollAlignCenter, Inline: ScrollAlignNearest},
+			expect: `{"scrollIntoView":{"selector":"#item-5","behavior":"auto","block":"center",` +
+				`"inline":"nearest"}}`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			ScrollIntoView(r, c.sel, c.opts)
+
+			h := headers(r)
+			if actual := h.Get("HX-Trigger-After-Settle"); actual != c.expect {
+				t.Errorf("HX-Trigger-After-Settle = %q, expected %q", actual, c.expect)
+			}
+			if actual := h.Get("HX-Trigger"); actual != "" {
+				t.Errorf("HX-Trigger = %q, expected it to be absent", actual)
+			}
+		})
+	}
+}
+
+func TestFocusAfterSwap(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		sels   []Selector
+		expect string
+	}{
+		{name: "selector", sels: []Selector{"#email"}, expect: `{"focusElement":{"selector":"#email"}}`},
+		{name: "overwrite", sels: []Selector{"#email", "#name"}, expect: `{"focusElement":{"selector":"#name"}}`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			for _, sel := range c.sels {
+				FocusAfterSwap(r, sel)
+			}
+
+			h := headers(r)
+			if actual := h.Get("HX-Trigger-After-Swap"); actual != c.expect {
+				t.Errorf("HX-Trigger-After-Swap = %q, expected %q", actual, c.expect)
+			}
+			for _, name := range []string{"HX-Trigger", "HX-Trigger-After-Settle"} {
+				if actual := h.Get(name); actual != "" {
+					t.Errorf("%s = %q, expected it to be absent", name, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestRedirectAfter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		u      URL
+		delay  time.Duration
+		expect string
+	}{
+		{
+			name:   "delay",
+			u:      "/orders/5",
+			delay:  2 * time.Second,
+			expect: `{"redirectAfter":{"delay":2000,"url":"/orders/5"},"toast":"Order placed"}`,
+		},
+		{
+			name:   "no delay",
+			u:      "/orders/5",
+			expect: `{"redirectAfter":{"delay":0,"url":"/orders/5"},"toast":"Order placed"}`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			_ = Trigger(r, "toast", "Order placed")
+			RedirectAfter(r, c.u, c.delay)
+
+			h := headers(r)
+			if actual := h.Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+			if actual := h.Get("HX-Redirect"); actual != "" {
+				t.Errorf("HX-Redirect = %q, expected it to be absent", actual)
+			}
+		})
+	}
+}
+
+func TestUpdateCounter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		delta  int
+		expect string
+	}{
+		{name: "increment", delta: 1, expect: `{"unreadCount":{"delta":1}}`},
+		{name: "decrement", delta: -3, expect: `{"unreadCount":{"delta":-3}}`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			UpdateCounter(r, "unreadCount", c.delta)
+
+			if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSetCounter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		value  int
+		expect string
+	}{
+		{name: "value", value: 5, expect: `{"unreadCount":{"value":5}}`},
+		{name: "zero", value: 0, expect: `{"unreadCount":{"value":0}}`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			UpdateCounter(r, "unreadCount", 1)
+			SetCounter(r, "unreadCount", c.value)
+
+			if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestTriggerError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		message string
+		expect  string
+	}{
+		{name: "message", message: "Something went wrong.", expect: `{"showError":{"error":"Something went wrong."}}`},
+		{name: "empty", message: "", expect: `{"showError":{"error":""}}`},
+		{name: "escaped", message: `"a"`, expect: `{"showError":{"error":"\"a\""}}`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			TriggerError(r, c.message)
+
+			if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package htmx
+
+import (
+	"context"
+	"net/http"
+)
+
+// NewResponseWriterWrapper wraps w in the response writer used by the
+// middleware, and attaches new response headers to r, just like the
+// middleware does.
+func NewResponseWriterWrapper(w http.ResponseWriter, r *http.Request, opts ...MiddlewareOption) http.ResponseWriter {
+	var o middlewareOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	h := newResponseHeaders()
+	*r = *r.WithContext(context.WithValue(r.Context(), ctxKey{}, h))
+	return newResponseWriterWrapper(w, r, h, &o)
+}
+
+// WroteHXHeader reports whether the htmx headers were written to w, which
+// must have been created using NewResponseWriterWrapper.
+func WroteHXHeader(w http.ResponseWriter) bool {
+	return w.(*responseWriterWrapper).wroteHeaders
+}
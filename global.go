@@ -1,9 +1,12 @@
 package htmx
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 )
 
 // LocationData is a location used as the HX-LocationData response header.
@@ -24,11 +27,122 @@ type LocationData struct {
 	// target.
 	Swap SwapStrategy
 	// Values are the values to submit with the request.
+	//
+	// If Values is of type [JSON], e.g. because it was created using
+	// [LocationValues], it is used as is, instead of being marshalled again.
+	// It must still be valid json, though.
 	Values any
 	// Headers are the headers to submit with the request.
 	Headers Headers
 }
 
+// NewLocation creates a new [LocationData] with the passed path.
+//
+// The remaining fields can be set using the With methods, each of which
+// returns a copy of the location:
+//
+//	htmx.Location(r, htmx.NewLocation("/inbox").WithTarget("#main").WithSwap(htmx.SwapOuterHTML))
+func NewLocation(path URL) LocationData {
+	return LocationData{Path: path}
+}
+
+// WithSource returns a copy of d with [LocationData.Source] set to sel.
+func (d LocationData) WithSource(sel Selector) LocationData {
+	d.Source = sel
+	return d
+}
+
+// WithEvent returns a copy of d with [LocationData.Event] set to event.
+func (d LocationData) WithEvent(event Event) LocationData {
+	d.Event = event
+	return d
+}
+
+// WithHandler returns a copy of d with [LocationData.Handler] set to
+// handler.
+func (d LocationData) WithHandler(handler JS) LocationData {
+	d.Handler = handler
+	return d
+}
+
+// WithTarget returns a copy of d with [LocationData.Target] set to sel.
+func (d LocationData) WithTarget(sel Selector) LocationData {
+	d.Target = sel
+	return d
+}
+
+// WithSwap returns a copy of d with [LocationData.Swap] set to strategy.
+func (d LocationData) WithSwap(strategy SwapStrategy) LocationData {
+	d.Swap = strategy
+	return d
+}
+
+// WithValues returns a copy of d with [LocationData.Values] set to values.
+//
+// values is marshalled to json, when the location is passed to [Location],
+// which returns an error, if that fails.
+// Use [LocationValues] to marshal values beforehand.
+func (d LocationData) WithValues(values any) LocationData {
+	d.Values = values
+	return d
+}
+
+// WithHeaders returns a copy of d with [LocationData.Headers] set to h.
+func (d LocationData) WithHeaders(h Headers) LocationData {
+	d.Headers = h
+	return d
+}
+
+// LocationValues marshals v to json, so that it can be used as
+// [LocationData.Values].
+//
+// Unlike passing v directly, marshalling errors surface when the location is
+// created, not when it is passed to [Location].
+// Additionally, the returned [JSON] can be reused across multiple requests,
+// without marshalling v again.
+func LocationValues[T any](v T) (JSON, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("HX-Location: Values: %w", err)
+	}
+
+	return data, nil
+}
+
+// Validate checks that d is a valid location.
+//
+// Path is mandatory, if any of the other fields are set.
+// Additionally, if set, Swap must not be malformed, i.e. its style may only
+// consist of letters, digits, dashes, and underscores, and Source and Target
+// must not be obviously invalid selectors.
+// Swap styles are not limited to the known ones, since extensions may define
+// their own.
+func (d *LocationData) Validate() error {
+	if d.Path == "" {
+		if d.Source != "" || d.Event != "" || d.Handler != "" || d.Target != "" || d.Swap != "" ||
+			d.Values != nil || len(d.Headers) > 0 {
+			return errors.New("HX-Location: Path is required, if other fields are set")
+		}
+		return nil
+	}
+
+	if d.Swap != "" && !d.Swap.valid() {
+		return fmt.Errorf("HX-Location: Swap: malformed swap strategy %q", d.Swap)
+	}
+	if d.Source != "" {
+		if err := validateSelector(d.Source); err != nil {
+			return fmt.Errorf("HX-Location: Source: %w", err)
+		}
+	}
+	if d.Target != "" {
+		if err := validateSelector(d.Target); err != nil {
+			return fmt.Errorf("HX-Location: Target: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (d *LocationData) toHeader() (LocationHeader, error) {
 	h := LocationHeader{
 		Path:    d.Path,
@@ -39,7 +153,13 @@ func (d *LocationData) toHeader() (LocationHeader, error) {
 		Swap:    d.Swap,
 		Headers: d.Headers,
 	}
-	if h.Values != nil {
+	if values, ok := d.Values.(JSON); ok {
+		if len(values) > 0 && !json.Valid(values) {
+			return h, errors.New("HX-Location: Values: invalid json")
+		}
+
+		h.Values = values
+	} else if d.Values != nil {
 		values, err := json.Marshal(d.Values)
 		if err != nil {
 			return h, fmt.Errorf("HX-Location: Values: %w", err)
@@ -73,21 +193,66 @@ func (d *LocationData) toHeader() (LocationHeader, error) {
 //
 // If LocationData.Path is an empty string, the location is not
 // included.
+// However, if any of the other fields are set, Path is mandatory.
+//
+// Before the location is set, it is validated using
+// [LocationData.Validate], and any validation errors are returned.
 //
 // Previous values are overwritten.
 func Location(r *http.Request, loc LocationData) error {
+	if err := loc.Validate(); err != nil {
+		return err
+	}
+
 	h, err := loc.toHeader()
 	if err != nil {
 		return err
 	}
 
-	Response(r).Location = h
+	if resp := attachedResponse(r); resp != nil {
+		resp.Location = h
+	}
 	return nil
 }
 
 // LocationPath is a shorthand for Location(r, LocationData{Path: path}).
 func LocationPath(r *http.Request, path URL) {
-	Response(r).Location = LocationHeader{Path: path}
+	if resp := attachedResponse(r); resp != nil {
+		resp.Location = LocationHeader{Path: path}
+	}
+}
+
+// ClearLocation removes a previously set HX-Location.
+//
+// If no location is set, ClearLocation is a no-op.
+func ClearLocation(r *http.Request) {
+	if resp := attachedResponse(r); resp != nil {
+		resp.Location = LocationHeader{}
+	}
+}
+
+// ClearResponse resets all response headers to their initial state, e.g. to
+// discard the headers set by a handler that failed midway, before writing an
+// error page.
+//
+// It may be called multiple times, and setters may be called afterwards as
+// usual.
+func ClearResponse(r *http.Request) {
+	if resp := attachedResponse(r); resp != nil {
+		*resp = *newResponseHeaders()
+	}
+}
+
+// ResponseModified reports whether any of the response headers of r were
+// set, e.g. to collect metrics on how much of an application is driven by
+// htmx.
+//
+// It is computed from the state of [Response], so triggers that were set
+// and then removed again, e.g. using [Untrigger], don't count.
+// The status set using [SetStatus] is not considered a header.
+func ResponseModified(r *http.Request) bool {
+	resp := attachedResponse(r)
+	return resp != nil && resp.modified()
 }
 
 // PushURL pushes a new url into the history stack:
@@ -108,8 +273,44 @@ func LocationPath(r *http.Request, path URL) {
 //
 // Previous values are overwritten.
 func PushURL(r *http.Request, u SameOriginURL) {
-	resp := Response(r)
-	resp.PushURL = u
+	if resp := attachedResponse(r); resp != nil {
+		resp.PushURL = u
+	}
+}
+
+// PushURLChecked is the same as [PushURL], but returns an error wrapping
+// [ErrCrossOrigin], if u is not of the same origin as r.
+// In that case, HX-Push-Url remains unchanged.
+//
+// Relative paths and "false" are always allowed, while URLs containing
+// backslashes are always rejected, as browsers treat them like slashes.
+//
+// Previous values are overwritten.
+func PushURLChecked(r *http.Request, u SameOriginURL) error {
+	if !sameOrigin(r, u) {
+		return fmt.Errorf("HX-Push-Url: %q: %w", u, ErrCrossOrigin)
+	}
+
+	PushURL(r, u)
+	return nil
+}
+
+// PushURLIfChanged is the same as [PushURL], but only pushes u, if it
+// differs from the current URL of the browser, as sent in the HX-Current-Url
+// request header.
+// This prevents duplicate history entries.
+//
+// Only the paths and queries of the URLs are compared.
+// Trailing slashes and the order of query parameters are ignored.
+// If the current URL is unknown, u is always pushed.
+//
+// Previous values are overwritten, if u is pushed.
+func PushURLIfChanged(r *http.Request, u SameOriginURL) {
+	if req := Request(r); req != nil && req.CurrentURL != "" && samePathAndQuery(req.CurrentURL, u) {
+		return
+	}
+
+	PushURL(r, u)
 }
 
 // PreventPushURL sets the HX-PushURL Header to "false".
@@ -118,15 +319,71 @@ func PushURL(r *http.Request, u SameOriginURL) {
 //
 // Previous values are overwritten.
 func PreventPushURL(r *http.Request) {
-	resp := Response(r)
-	resp.PushURL = "false"
+	if resp := attachedResponse(r); resp != nil {
+		resp.PushURL = "false"
+	}
 }
 
 // Redirect can be used to do a client-side redirect to a new location.
 //
 // Previous values are overwritten.
 func Redirect(r *http.Request, u URL) {
-	Response(r).Redirect = u
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.Redirect = u
+	resp.externalRedirect = false
+}
+
+// RedirectOnly is the same as [Redirect], but additionally clears the
+// response headers that are irrelevant, when doing a full redirect.
+//
+// These are HX-Location, HX-Reswap, HX-Retarget, and HX-Reselect, i.e. the
+// fields Location, Reswap, Retarget, and Reselect of [ResponseHeaders].
+// All other headers, notably the triggers, are kept.
+func RedirectOnly(r *http.Request, u URL) {
+	Redirect(r, u)
+
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.Location = LocationHeader{}
+	resp.Reswap = ""
+	resp.Retarget = ""
+	resp.Reselect = ""
+}
+
+// ExternalRedirect is the same as [Redirect], but marks the redirect as
+// intentionally going to another origin.
+//
+// This is only necessary, if the middleware was created using
+// [WithSameOriginURLs], which would otherwise drop the redirect.
+//
+// Previous values are overwritten.
+func ExternalRedirect(r *http.Request, u URL) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.Redirect = u
+	resp.externalRedirect = true
+}
+
+// ClearRedirect removes a previously set HX-Redirect.
+//
+// This is useful e.g. for error handling middleware, that needs to replace
+// the redirect of a failed handler with an error fragment.
+//
+// If no redirect is set, ClearRedirect is a no-op.
+func ClearRedirect(r *http.Request) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.Redirect = ""
+	resp.externalRedirect = false
 }
 
 // Refresh if set to “true”, will do a full refresh of the page on the
@@ -134,7 +391,9 @@ func Redirect(r *http.Request, u URL) {
 //
 // Previous values are overwritten.
 func Refresh(r *http.Request, refresh bool) {
-	Response(r).Refresh = refresh
+	if resp := attachedResponse(r); resp != nil {
+		resp.Refresh = refresh
+	}
 }
 
 // ReplaceURL allows you to replace the current URL in the browser
@@ -153,7 +412,26 @@ func Refresh(r *http.Request, refresh bool) {
 //
 // Previous values are overwritten.
 func ReplaceURL(r *http.Request, u SameOriginURL) {
-	Response(r).ReplaceURL = u
+	if resp := attachedResponse(r); resp != nil {
+		resp.ReplaceURL = u
+	}
+}
+
+// ReplaceURLChecked is the same as [ReplaceURL], but returns an error
+// wrapping [ErrCrossOrigin], if u is not of the same origin as r.
+// In that case, HX-Replace-Url remains unchanged.
+//
+// Relative paths and "false" are always allowed, while URLs containing
+// backslashes are always rejected, as browsers treat them like slashes.
+//
+// Previous values are overwritten.
+func ReplaceURLChecked(r *http.Request, u SameOriginURL) error {
+	if !sameOrigin(r, u) {
+		return fmt.Errorf("HX-Replace-Url: %q: %w", u, ErrCrossOrigin)
+	}
+
+	ReplaceURL(r, u)
+	return nil
 }
 
 // PreventReplaceURL sets the HX-ReplaceURL Header to "false".
@@ -162,15 +440,22 @@ func ReplaceURL(r *http.Request, u SameOriginURL) {
 //
 // Previous values are overwritten.
 func PreventReplaceURL(r *http.Request) {
-	resp := Response(r)
-	resp.ReplaceURL = "false"
+	if resp := attachedResponse(r); resp != nil {
+		resp.ReplaceURL = "false"
+	}
 }
 
 // Reswap allows you to specify how the response will be swapped.
 //
+// [SwapNone] is a regular strategy, and is always sent as "HX-Reswap: none".
+// Passing an empty strategy, on the other hand, removes a previously set
+// HX-Reswap header.
+//
 // Previous values are overwritten.
 func Reswap(r *http.Request, strategy SwapStrategy) {
-	Response(r).Reswap = strategy
+	if resp := attachedResponse(r); resp != nil {
+		resp.Reswap = strategy
+	}
 }
 
 // Retarget is a CSS selector that updates the target of the content
@@ -178,7 +463,49 @@ func Reswap(r *http.Request, strategy SwapStrategy) {
 //
 // Previous values are overwritten.
 func Retarget(r *http.Request, sel Selector) {
-	Response(r).Retarget = sel
+	if resp := attachedResponse(r); resp != nil {
+		resp.Retarget = sel
+	}
+}
+
+// RetargetChecked is the same as [Retarget], but returns an error, if sel is
+// obviously not a valid selector, e.g. because it is empty or has unbalanced
+// brackets.
+// In that case, HX-Retarget remains unchanged.
+//
+// This is not a full validation, but catches typos that would otherwise
+// silently fail on the client.
+//
+// Previous values are overwritten.
+func RetargetChecked(r *http.Request, sel Selector) error {
+	if err := validateSelector(sel); err != nil {
+		return fmt.Errorf("HX-Retarget: %q: %w", sel, err)
+	}
+
+	Retarget(r, sel)
+	return nil
+}
+
+// RetargetClosest retargets the response to the closest ancestor of the
+// triggering element matching sel, e.g. the table row containing a button,
+// by setting HX-Retarget to [Closest](sel).
+//
+// This requires a version of htmx that supports extended selectors in the
+// HX-Retarget header.
+//
+// An error is returned if sel is obviously not a valid selector, as for
+// [RetargetChecked].
+//
+// Previous values are overwritten.
+func RetargetClosest(r *http.Request, sel Selector) error {
+	if err := validateSelector(sel); err != nil {
+		return fmt.Errorf("HX-Retarget: %w", err)
+	}
+
+	if resp := attachedResponse(r); resp != nil {
+		resp.Retarget = Closest(sel)
+	}
+	return nil
 }
 
 // Reselect is a CSS selector that allows you to choose which part of
@@ -186,7 +513,59 @@ func Retarget(r *http.Request, sel Selector) {
 //
 // Previous values are overwritten.
 func Reselect(r *http.Request, sel Selector) {
-	Response(r).Reselect = sel
+	if resp := attachedResponse(r); resp != nil {
+		resp.Reselect = sel
+	}
+}
+
+// ReselectChecked is the same as [Reselect], but returns an error, if sel is
+// obviously not a valid selector, like [RetargetChecked].
+// In that case, HX-Reselect remains unchanged.
+//
+// Previous values are overwritten.
+func ReselectChecked(r *http.Request, sel Selector) error {
+	if err := validateSelector(sel); err != nil {
+		return fmt.Errorf("HX-Reselect: %q: %w", sel, err)
+	}
+
+	Reselect(r, sel)
+	return nil
+}
+
+// SetStatus sets the status code of the response.
+//
+// The status is written by the middleware, unless the handler writes a
+// status itself by calling WriteHeader.
+// This allows influencing the status from code that can't access the
+// [http.ResponseWriter], or handlers that only call Write.
+//
+// Previous values are overwritten.
+func SetStatus(r *http.Request, code int) {
+	if resp := attachedResponse(r); resp != nil {
+		resp.status = code
+	}
+}
+
+// AddExtraHeader adds the passed value to the extra, non-htmx header with
+// the passed name, e.g. X-App-Version, which is sent along with the htmx
+// headers.
+//
+// Extra headers are written at the same time as the htmx headers, i.e.
+// before the body.
+// Unlike headers set on the [http.ResponseWriter] directly, they can be set
+// without access to the response writer, and are reset by [ClearResponse].
+//
+// The value is appended to previous values of the header.
+func AddExtraHeader(r *http.Request, key, value string) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	if resp.Extra == nil {
+		resp.Extra = make(http.Header)
+	}
+
+	resp.Extra.Add(key, value)
 }
 
 // Trigger triggers the passed event as soon as the response is received.
@@ -205,7 +584,79 @@ func Trigger(r *http.Request, name Event, data any) error {
 		}
 	}
 
-	Response(r).Trigger[name] = jsonData
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[name] = jsonData
+	}
+	return nil
+}
+
+// TriggerEvent triggers the passed event without data as soon as the
+// response is received.
+//
+// If a there already is a trigger for that event, it will be overwritten.
+//
+// If none of the triggered events have data, HX-Trigger is a comma-separated
+// list of the events, e.g. "closeModal,refreshTable".
+// Otherwise, events without data are included in the JSON object with a
+// null detail.
+func TriggerEvent(r *http.Request, name Event) {
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[name] = nil
+	}
+}
+
+// TriggerAppend triggers the passed event as soon as the response is
+// received, appending data to the payloads of previous calls to TriggerAppend
+// for the same event, instead of overwriting them.
+//
+// Since HX-Trigger can only contain a single detail per event, the detail of
+// an event triggered using TriggerAppend is always a JSON array of all
+// appended payloads, in the order they were appended.
+// nil data is appended as null.
+// If the event was previously triggered using another function, e.g.
+// [Trigger], its detail becomes the first element of the array.
+//
+// htmx wraps details that aren't objects, so the client can access the array
+// using evt.detail.value.
+//
+// An error will only be returned if data can't be marshalled to json.
+func TriggerAppend(r *http.Request, name Event, data any) error {
+	jsonData, err := marshalTriggerData(data)
+	if err != nil {
+		return err
+	}
+	if jsonData == nil {
+		jsonData = JSON("null")
+	}
+
+	resp := attachedResponse(r)
+	if resp == nil {
+		return nil
+	}
+
+	var payloads []JSON
+	if existing, ok := resp.Trigger[name]; ok {
+		if appended, ok := resp.appended[name]; ok && bytes.Equal(existing, appended) {
+			if err := json.Unmarshal(existing, &payloads); err != nil {
+				return err
+			}
+		} else if existing == nil {
+			payloads = []JSON{JSON("null")}
+		} else {
+			payloads = []JSON{existing}
+		}
+	}
+
+	jsonData, err = json.Marshal(append(payloads, jsonData))
+	if err != nil {
+		return err
+	}
+
+	if resp.appended == nil {
+		resp.appended = make(map[Event]JSON)
+	}
+	resp.Trigger[name] = jsonData
+	resp.appended[name] = jsonData
 	return nil
 }
 
@@ -227,7 +678,9 @@ func TriggerAfterSettle(r *http.Request, name Event, data any) error {
 		}
 	}
 
-	Response(r).TriggerAfterSettle[name] = jsonData
+	if resp := attachedResponse(r); resp != nil {
+		resp.TriggerAfterSettle[name] = jsonData
+	}
 	return nil
 }
 
@@ -249,6 +702,338 @@ func TriggerAfterSwap(r *http.Request, name Event, data any) error {
 		}
 	}
 
-	Response(r).TriggerAfterSwap[name] = jsonData
+	if resp := attachedResponse(r); resp != nil {
+		resp.TriggerAfterSwap[name] = jsonData
+	}
+	return nil
+}
+
+// Untrigger removes the trigger for the passed event, that was set using
+// [Trigger] or a similar function.
+//
+// If the event isn't triggered, Untrigger is a no-op.
+func Untrigger(r *http.Request, name Event) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	delete(resp.Trigger, name)
+	delete(resp.appended, name)
+}
+
+// UntriggerAfterSettle removes the after-settle trigger for the passed event.
+//
+// If the event isn't triggered, UntriggerAfterSettle is a no-op.
+func UntriggerAfterSettle(r *http.Request, name Event) {
+	if resp := attachedResponse(r); resp != nil {
+		delete(resp.TriggerAfterSettle, name)
+	}
+}
+
+// UntriggerAfterSwap removes the after-swap trigger for the passed event.
+//
+// If the event isn't triggered, UntriggerAfterSwap is a no-op.
+func UntriggerAfterSwap(r *http.Request, name Event) {
+	if resp := attachedResponse(r); resp != nil {
+		delete(resp.TriggerAfterSwap, name)
+	}
+}
+
+// TriggerAnimated triggers the passed event once the swapped content has
+// settled, i.e. after htmx has finished its settle transitions.
+//
+// Use it for events whose handlers need to measure or animate the new
+// content, as CSS transitions applied during settling have finished by then.
+//
+// It is the same as calling [TriggerAfterSettle].
+func TriggerAnimated(r *http.Request, name Event, data any) error {
+	return TriggerAfterSettle(r, name, data)
+}
+
+// TriggerImmediatePostSwap triggers the passed event directly after the new
+// content was swapped into the DOM, but before it has settled.
+//
+// Use it for events whose handlers need to access the new content as soon
+// as possible, e.g. to initialize third-party libraries on it.
+//
+// It is the same as calling [TriggerAfterSwap].
+func TriggerImmediatePostSwap(r *http.Request, name Event, data any) error {
+	return TriggerAfterSwap(r, name, data)
+}
+
+// TriggerE is the chainable version of [Trigger].
+//
+// Instead of returning an error, it records it in the returned
+// [ResponseHeaders], allowing you to queue multiple triggers fluently and
+// check for errors once:
+//
+//	err := htmx.TriggerE(r, "reload-nav", navData).
+//		TriggerE("update-cart", cartData).
+//		TriggerAfterSwapE("focus-search", nil).
+//		Err()
+//
+// See [ResponseHeaders.TriggerE] for details on how errors accumulate.
+func TriggerE(r *http.Request, name Event, data any) *ResponseHeaders {
+	return Response(r).TriggerE(name, data)
+}
+
+// TriggerMerge triggers the passed event as soon as the response is
+// received, and shallowly merges data into the event's existing detail.
+//
+// If the event isn't already triggered, or triggered without data, data
+// becomes the detail.
+// Otherwise, the existing detail must be a JSON object, whose keys are
+// overwritten by those of data.
+// Keys not present in data are kept.
+//
+// This allows building up the detail of an event across multiple layers,
+// e.g. middlewares.
+//
+// An error will be returned, if the existing detail is not a JSON object, or
+// if data can't be marshalled to json.
+func TriggerMerge(r *http.Request, name Event, data map[string]any) error {
+	resp := attachedResponse(r)
+
+	var existing JSON
+	if resp != nil {
+		existing = resp.Trigger[name]
+	}
+
+	merged := make(map[string]JSON, len(data))
+	if existing != nil && string(existing) != "null" {
+		if err := json.Unmarshal(existing, &merged); err != nil {
+			return fmt.Errorf("%s: existing detail is not an object: %w", name, err)
+		}
+	}
+
+	for k, v := range data {
+		jsonVal, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", name, k, err)
+		}
+		merged[k] = jsonVal
+	}
+
+	jsonData, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	if resp != nil {
+		resp.Trigger[name] = jsonData
+	}
 	return nil
 }
+
+type (
+	// HistoryOptions describe how the browser's history is updated by
+	// [History].
+	//
+	// They can only be created using [PushHistory], [ReplaceHistory], and
+	// [PreventHistory], which ensures that pushing and replacing are never
+	// combined.
+	HistoryOptions struct {
+		mode historyMode
+		u    SameOriginURL
+	}
+
+	historyMode uint8
+)
+
+const (
+	historyModeUnset historyMode = iota
+	historyModePush
+	historyModeReplace
+	historyModePrevent
+)
+
+// PushHistory returns [HistoryOptions] that push u into the browser's
+// history, creating a new entry.
+func PushHistory(u SameOriginURL) HistoryOptions {
+	return HistoryOptions{mode: historyModePush, u: u}
+}
+
+// ReplaceHistory returns [HistoryOptions] that replace the current entry of
+// the browser's history with u.
+func ReplaceHistory(u SameOriginURL) HistoryOptions {
+	return HistoryOptions{mode: historyModeReplace, u: u}
+}
+
+// PreventHistory returns [HistoryOptions] that prevent the browser's history
+// from being updated, regardless of hx-push-url and hx-replace-url
+// attributes.
+func PreventHistory() HistoryOptions {
+	return HistoryOptions{mode: historyModePrevent}
+}
+
+// History updates the browser's history as described by opts.
+//
+// Unlike calling [PushURL] and [ReplaceURL] directly, History ensures that
+// only one of HX-Push-Url and HX-Replace-Url is set:
+//
+//   - [PushHistory] sets HX-Push-Url, and removes HX-Replace-Url.
+//   - [ReplaceHistory] sets HX-Replace-Url, and removes HX-Push-Url.
+//   - [PreventHistory] sets both to "false".
+//
+// An error is returned, if opts is the zero value, or if the URL is not of
+// the same origin as r.
+// In that case, the headers remain unchanged.
+//
+// Previous values are overwritten.
+func History(r *http.Request, opts HistoryOptions) error {
+	switch opts.mode {
+	case historyModePush:
+		if err := PushURLChecked(r, opts.u); err != nil {
+			return err
+		}
+		ReplaceURL(r, "")
+	case historyModeReplace:
+		if err := ReplaceURLChecked(r, opts.u); err != nil {
+			return err
+		}
+		PushURL(r, "")
+	case historyModePrevent:
+		PreventPushURL(r)
+		PreventReplaceURL(r)
+	default:
+		return errors.New("htmx: History: options must be created using PushHistory, ReplaceHistory, or PreventHistory")
+	}
+
+	return nil
+}
+
+// TriggerFields triggers the passed event as soon as the response is
+// received, using only the passed fields of v as detail.
+//
+// v is marshalled to json, and must result in a JSON object.
+// fields are the names of the object's keys, i.e. the names given by the
+// json tags of v, if it is a struct.
+// Fields that don't exist in the marshalled object are omitted.
+//
+// This prevents leaking the full representation of v to the client, when
+// only some fields are needed.
+//
+// If a there already is a trigger for that event, it will be overwritten.
+//
+// An error will be returned, if v can't be marshalled to json, or doesn't
+// result in a JSON object.
+func TriggerFields(r *http.Request, name Event, v any, fields ...string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var all map[string]JSON
+	if err := json.Unmarshal(data, &all); err != nil {
+		return fmt.Errorf("%s: value is not an object: %w", name, err)
+	}
+
+	filtered := make(map[string]JSON, len(fields))
+	for _, f := range fields {
+		if val, ok := all[f]; ok {
+			filtered[f] = val
+		}
+	}
+
+	jsonData, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[name] = jsonData
+	}
+	return nil
+}
+
+// Directive is a set of response headers applied at once using [Apply].
+//
+// Zero fields are ignored.
+type Directive struct {
+	// Retarget sets HX-Retarget, see [Retarget].
+	Retarget Selector
+	// Reswap sets HX-Reswap, see [Reswap].
+	Reswap SwapStrategy
+	// Reselect sets HX-Reselect, see [Reselect].
+	Reselect Selector
+	// PushURL sets HX-Push-Url, see [PushURL].
+	PushURL SameOriginURL
+	// ReplaceURL sets HX-Replace-Url, see [ReplaceURL].
+	ReplaceURL SameOriginURL
+	// Redirect sets HX-Redirect, see [Redirect].
+	Redirect URL
+	// Refresh sets HX-Refresh, see [Refresh].
+	Refresh bool
+	// Trigger triggers the events as soon as the response is received, see
+	// [Trigger].
+	Trigger map[Event]any
+	// TriggerAfterSettle triggers the events after the settling step, see
+	// [TriggerAfterSettle].
+	TriggerAfterSettle map[Event]any
+	// TriggerAfterSwap triggers the events after the swap step, see
+	// [TriggerAfterSwap].
+	TriggerAfterSwap map[Event]any
+}
+
+// Apply sets the response headers of all non-zero fields of d, e.g.:
+//
+//	htmx.Apply(r, htmx.Directive{
+//	    Retarget: "#list",
+//	    Reswap:   htmx.SwapOuterHTML,
+//	    Trigger:  map[htmx.Event]any{"itemAdded": item},
+//	})
+//
+// Previous values are overwritten.
+//
+// An error will only be returned if the data of one or more triggers can't
+// be marshalled to json.
+// In that case, the errors of all such triggers are joined using
+// [errors.Join], and only the triggers without errors are set.
+func Apply(r *http.Request, d Directive) error {
+	if d.Retarget != "" {
+		Retarget(r, d.Retarget)
+	}
+	if d.Reswap != "" {
+		Reswap(r, d.Reswap)
+	}
+	if d.Reselect != "" {
+		Reselect(r, d.Reselect)
+	}
+	if d.PushURL != "" {
+		PushURL(r, d.PushURL)
+	}
+	if d.ReplaceURL != "" {
+		ReplaceURL(r, d.ReplaceURL)
+	}
+	if d.Redirect != "" {
+		Redirect(r, d.Redirect)
+	}
+	if d.Refresh {
+		Refresh(r, true)
+	}
+
+	return errors.Join(
+		applyTriggers(r, "HX-Trigger", d.Trigger, Trigger),
+		applyTriggers(r, "HX-Trigger-After-Settle", d.TriggerAfterSettle, TriggerAfterSettle),
+		applyTriggers(r, "HX-Trigger-After-Swap", d.TriggerAfterSwap, TriggerAfterSwap),
+	)
+}
+
+func applyTriggers(
+	r *http.Request, header string, triggers map[Event]any, trigger func(*http.Request, Event, any) error,
+) error {
+	names := make([]Event, 0, len(triggers))
+	for name := range triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names) // deterministic error order
+
+	var errs []error
+	for _, name := range names {
+		if err := trigger(r, name, triggers[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", header, name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,1433 @@
+package htmx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSetStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		write  func(w http.ResponseWriter)
+		expect int
+	}{
+		{
+			name:   "no write",
+			write:  func(http.ResponseWriter) {},
+			expect: StatusStopPolling,
+		},
+		{
+			name:   "write",
+			write:  func(w http.ResponseWriter) { _, _ = io.WriteString(w, "abc") },
+			expect: StatusStopPolling,
+		},
+		{
+			name:   "flush",
+			write:  func(w http.ResponseWriter) { _ = http.NewResponseController(w).Flush() },
+			expect: StatusStopPolling,
+		},
+		{
+			name:   "explicit status",
+			write:  func(w http.ResponseWriter) { w.WriteHeader(http.StatusTeapot) },
+			expect: http.StatusTeapot,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+				SetStatus(r, StatusStopPolling)
+				c.write(w)
+			})
+
+			if rec.Code != c.expect {
+				t.Errorf("status = %d, expected %d", rec.Code, c.expect)
+			}
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name           string
+		loc            LocationData
+		expectLocation string
+	}{
+		{name: "empty", loc: LocationData{}},
+		{name: "path only", loc: LocationData{Path: "/a"}, expectLocation: "/a"},
+		{
+			name:           "target",
+			loc:            LocationData{Path: "/a", Target: "#main"},
+			expectLocation: `{"path":"/a","target":"#main"}`,
+		},
+		{
+			name:           "text content swap",
+			loc:            LocationData{Path: "/a", Swap: SwapTextContent},
+			expectLocation: `{"path":"/a","swap":"textContent"}`,
+		},
+		{
+			name:           "extension swap",
+			loc:            LocationData{Path: "/a", Swap: "morph"},
+			expectLocation: `{"path":"/a","swap":"morph"}`,
+		},
+		{
+			name:           "values",
+			loc:            LocationData{Path: "/a", Values: map[string]int{"b": 1}},
+			expectLocation: `{"path":"/a","values":{"b":1}}`,
+		},
+		{
+			name:           "json values",
+			loc:            LocationData{Path: "/a", Values: JSON(`{"b":1}`)},
+			expectLocation: `{"path":"/a","values":{"b":1}}`,
+		},
+	}
+
+	failureCases := []struct {
+		name string
+		loc  LocationData
+	}{
+		{name: "missing path", loc: LocationData{Target: "#main"}},
+		{name: "malformed swap", loc: LocationData{Path: "/a", Swap: "inner<HTML>"}},
+		{name: "invalid target", loc: LocationData{Path: "/a", Target: "a["}},
+		{name: "invalid values", loc: LocationData{Path: "/a", Values: func() {}}},
+		{name: "invalid json values", loc: LocationData{Path: "/a", Values: JSON("{")}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := Location(r, c.loc); err != nil {
+					t.Fatalf("Location returned error: %v", err)
+				}
+
+				if actual := headers(r).Get("HX-Location"); actual != c.expectLocation {
+					t.Errorf("HX-Location = %q, expected %q", actual, c.expectLocation)
+				}
+			})
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := Location(r, c.loc); err == nil {
+					t.Fatal("expected Location to return an error")
+				}
+
+				if h := headers(r); len(h) > 0 {
+					t.Errorf("expected no headers to be set, got %v", h)
+				}
+			})
+		}
+	})
+}
+
+func TestAddExtraHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sent with htmx headers", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+			AddExtraHeader(r, "X-App-Version", "1.2.3")
+			AddExtraHeader(r, "X-Tags", "a")
+			AddExtraHeader(r, "X-Tags", "b")
+			Retarget(r, "#main")
+			_, _ = io.WriteString(w, "abc")
+		})
+
+		res := rec.Result()
+		defer res.Body.Close()
+
+		if actual := res.Header.Get("X-App-Version"); actual != "1.2.3" {
+			t.Errorf("X-App-Version = %q, expected %q", actual, "1.2.3")
+		}
+		if actual := res.Header.Values("X-Tags"); !reflect.DeepEqual(actual, []string{"a", "b"}) {
+			t.Errorf("X-Tags = %q, expected %q", actual, []string{"a", "b"})
+		}
+		if actual := res.Header.Get("HX-Retarget"); actual != "#main" {
+			t.Errorf("HX-Retarget = %q, expected %q", actual, "#main")
+		}
+	})
+
+	t.Run("reset by ClearResponse", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		AddExtraHeader(r, "X-App-Version", "1.2.3")
+		if !ResponseModified(r) {
+			t.Error("expected the response to be modified")
+		}
+
+		ClearResponse(r)
+		if h := headers(r); len(h) > 0 {
+			t.Errorf("expected no headers after ClearResponse, got %v", h)
+		}
+	})
+}
+
+func TestNewLocation(t *testing.T) {
+	t.Parallel()
+
+	base := NewLocation("/inbox")
+	actual := base.
+		WithSource("#source").
+		WithEvent("click").
+		WithHandler("handle").
+		WithTarget("#main").
+		WithSwap(SwapOuterHTML).
+		WithValues(map[string]int{"a": 1}).
+		WithHeaders(Headers{"X-A": "b"})
+
+	expect := LocationData{
+		Path:    "/inbox",
+		Source:  "#source",
+		Event:   "click",
+		Handler: "handle",
+		Target:  "#main",
+		Swap:    SwapOuterHTML,
+		Values:  map[string]int{"a": 1},
+		Headers: Headers{"X-A": "b"},
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Errorf("NewLocation() = %+v, expected %+v", actual, expect)
+	}
+
+	if !reflect.DeepEqual(base, LocationData{Path: "/inbox"}) {
+		t.Errorf("expected the With methods not to modify the original location, got %+v", base)
+	}
+}
+
+func TestLocationData_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		loc       LocationData
+		expectErr bool
+	}{
+		{name: "empty", loc: LocationData{}},
+		{name: "path only", loc: LocationData{Path: "/a"}},
+		{name: "all fields", loc: LocationData{
+			Path: "/a", Source: "#b", Event: "click", Handler: "handle", Target: "#c", Swap: SwapOuterHTML,
+			Values: map[string]int{"d": 1}, Headers: Headers{"X-E": "f"},
+		}},
+		{name: "swap with modifiers", loc: LocationData{Path: "/a", Swap: "innerHTML settle:1s"}},
+		{name: "missing path", loc: LocationData{Swap: SwapOuterHTML}, expectErr: true},
+		{name: "malformed swap", loc: LocationData{Path: "/a", Swap: "inner/HTML"}, expectErr: true},
+		{name: "blank source", loc: LocationData{Path: "/a", Source: " "}, expectErr: true},
+		{name: "blank target", loc: LocationData{Path: "/a", Target: " "}, expectErr: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := c.loc.Validate(); (err != nil) != c.expectErr {
+				t.Errorf("Validate() = %v, expected error: %t", err, c.expectErr)
+			}
+		})
+	}
+}
+
+func TestClearRedirect(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		redirect func(r *http.Request)
+	}{
+		{name: "unset", redirect: func(*http.Request) {}},
+		{name: "redirect", redirect: func(r *http.Request) { Redirect(r, "/login") }},
+		{name: "external redirect", redirect: func(r *http.Request) { ExternalRedirect(r, "https://example.org") }},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := func(_ http.ResponseWriter, r *http.Request) {
+				c.redirect(r)
+				panic("abc")
+			}
+
+			// error handling middleware, replacing the redirect of the failed
+			// handler with an error fragment
+			recoverer := func(w http.ResponseWriter, r *http.Request) {
+				defer func() {
+					if recover() != nil {
+						ClearRedirect(r)
+						Retarget(r, "#errors")
+						_, _ = io.WriteString(w, "<p>error</p>")
+					}
+				}()
+
+				inner(w, r)
+			}
+
+			rec := serve(NewMiddleware(), httptest.NewRequest(http.MethodGet, "/", nil), recoverer)
+
+			if actual := rec.Header().Get("HX-Redirect"); actual != "" {
+				t.Errorf("HX-Redirect = %q, expected it to be cleared", actual)
+			}
+			if actual := rec.Header().Get("HX-Retarget"); actual != "#errors" {
+				t.Errorf("HX-Retarget = %q, expected %q", actual, "#errors")
+			}
+			if actual := rec.Body.String(); actual != "<p>error</p>" {
+				t.Errorf("body = %q, expected %q", actual, "<p>error</p>")
+			}
+		})
+	}
+}
+
+func TestClearLocation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		location func(r *http.Request)
+	}{
+		{name: "unset", location: func(*http.Request) {}},
+		{name: "path", location: func(r *http.Request) { LocationPath(r, "/a") }},
+		{
+			name: "location data",
+			location: func(r *http.Request) {
+				_ = Location(r, LocationData{Path: "/a", Target: "#main"})
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			c.location(r)
+			ClearLocation(r)
+
+			if actual := headers(r).Get("HX-Location"); actual != "" {
+				t.Errorf("HX-Location = %q, expected it to be cleared", actual)
+			}
+
+			LocationPath(r, "/b")
+			if actual := headers(r).Get("HX-Location"); actual != "/b" {
+				t.Errorf("HX-Location = %q, expected %q after clearing", actual, "/b")
+			}
+		})
+	}
+}
+
+func TestRetargetClosest(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		sel    Selector
+		expect string
+	}{
+		{name: "element", sel: "tr", expect: "closest tr"},
+		{name: "attribute", sel: `[data-row="5"]`, expect: `closest [data-row="5"]`},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := RetargetClosest(r, c.sel); err != nil {
+					t.Fatalf("RetargetClosest returned error: %v", err)
+				}
+
+				if actual := headers(r).Get("HX-Retarget"); actual != c.expect {
+					t.Errorf("HX-Retarget = %q, expected %q", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name string
+		sel  Selector
+	}{
+		{name: "blank", sel: " "},
+		{name: "unbalanced", sel: "tr["},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				Retarget(r, "#main")
+				if err := RetargetClosest(r, c.sel); err == nil {
+					t.Fatal("expected RetargetClosest to return an error")
+				}
+
+				if actual := headers(r).Get("HX-Retarget"); actual != "#main" {
+					t.Errorf("HX-Retarget = %q, expected it to remain %q", actual, "#main")
+				}
+			})
+		}
+	})
+}
+
+func TestPushURLIfChanged(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		currentURL string
+		u          SameOriginURL
+		expect     string
+	}{
+		{name: "unknown current url", u: "/a", expect: "/a"},
+		{name: "same url", currentURL: "http://example.com/a?b=1", u: "/a?b=1", expect: ""},
+		{name: "same url normalized", currentURL: "http://example.com/a/?b=1&c=2", u: "/a?c=2&b=1", expect: ""},
+		{name: "different path", currentURL: "http://example.com/a", u: "/b", expect: "/b"},
+		{name: "different query", currentURL: "http://example.com/a?page=1", u: "/a?page=2", expect: "/a?page=2"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			r.Header.Set("HX-Request", "true")
+			if c.currentURL != "" {
+				r.Header.Set("HX-Current-Url", c.currentURL)
+			}
+
+			PushURLIfChanged(r, c.u)
+
+			if actual := headers(r).Get("HX-Push-Url"); actual != c.expect {
+				t.Errorf("HX-Push-Url = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestTriggerMerge(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		set    func(r *http.Request)
+		data   map[string]any
+		expect string
+	}{
+		{
+			name:   "new event",
+			set:    func(*http.Request) {},
+			data:   map[string]any{"a": 1},
+			expect: `{"notify":{"a":1}}`,
+		},
+		{
+			name:   "event without data",
+			set:    func(r *http.Request) { TriggerEvent(r, "notify") },
+			data:   map[string]any{"a": 1},
+			expect: `{"notify":{"a":1}}`,
+		},
+		{
+			name:   "merge two partial details",
+			set:    func(r *http.Request) { _ = TriggerMerge(r, "notify", map[string]any{"a": 1, "b": "c"}) },
+			data:   map[string]any{"b": "d", "e": true},
+			expect: `{"notify":{"a":1,"b":"d","e":true}}`,
+		},
+		{
+			name:   "merge into trigger",
+			set:    func(r *http.Request) { _ = Trigger(r, "notify", map[string]int{"a": 1}) },
+			data:   map[string]any{"b": 2},
+			expect: `{"notify":{"a":1,"b":2}}`,
+		},
+		{
+			name:   "nested objects are replaced",
+			set:    func(r *http.Request) { _ = Trigger(r, "notify", map[string]any{"a": map[string]int{"b": 1}}) },
+			data:   map[string]any{"a": map[string]int{"c": 2}},
+			expect: `{"notify":{"a":{"c":2}}}`,
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				c.set(r)
+				if err := TriggerMerge(r, "notify", c.data); err != nil {
+					t.Fatalf("TriggerMerge returned error: %v", err)
+				}
+
+				if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+					t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name string
+		set  func(r *http.Request)
+		data map[string]any
+	}{
+		{
+			name: "existing string",
+			set:  func(r *http.Request) { _ = Trigger(r, "notify", "abc") },
+			data: map[string]any{"a": 1},
+		},
+		{
+			name: "existing array",
+			set:  func(r *http.Request) { _ = Trigger(r, "notify", []int{1}) },
+			data: map[string]any{"a": 1},
+		},
+		{
+			name: "invalid data",
+			set:  func(*http.Request) {},
+			data: map[string]any{"a": func() {}},
+		},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				c.set(r)
+				before := headers(r).Get("HX-Trigger")
+
+				if err := TriggerMerge(r, "notify", c.data); err == nil {
+					t.Fatal("expected TriggerMerge to return an error")
+				}
+
+				if actual := headers(r).Get("HX-Trigger"); actual != before {
+					t.Errorf("HX-Trigger = %q, expected it to remain %q", actual, before)
+				}
+			})
+		}
+	})
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name             string
+		opts             HistoryOptions
+		expectPushURL    string
+		expectReplaceURL string
+	}{
+		{name: "push", opts: PushHistory("/a"), expectPushURL: "/a"},
+		{name: "replace", opts: ReplaceHistory("/a"), expectReplaceURL: "/a"},
+		{name: "prevent", opts: PreventHistory(), expectPushURL: "false", expectReplaceURL: "false"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				PushURL(r, "/b")
+				ReplaceURL(r, "/c")
+
+				if err := History(r, c.opts); err != nil {
+					t.Fatalf("History returned error: %v", err)
+				}
+
+				h := headers(r)
+				if actual := h.Get("HX-Push-Url"); actual != c.expectPushURL {
+					t.Errorf("HX-Push-Url = %q, expected %q", actual, c.expectPushURL)
+				}
+				if actual := h.Get("HX-Replace-Url"); actual != c.expectReplaceURL {
+					t.Errorf("HX-Replace-Url = %q, expected %q", actual, c.expectReplaceURL)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name string
+		opts HistoryOptions
+	}{
+		{name: "zero value", opts: HistoryOptions{}},
+		{name: "cross-origin push", opts: PushHistory("https://evil.com")},
+		{name: "cross-origin replace", opts: ReplaceHistory("//evil.com")},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				PushURL(r, "/b")
+				ReplaceURL(r, "/c")
+
+				if err := History(r, c.opts); err == nil {
+					t.Fatal("expected History to return an error")
+				}
+
+				h := headers(r)
+				if actual := h.Get("HX-Push-Url"); actual != "/b" {
+					t.Errorf("HX-Push-Url = %q, expected it to remain %q", actual, "/b")
+				}
+				if actual := h.Get("HX-Replace-Url"); actual != "/c" {
+					t.Errorf("HX-Replace-Url = %q, expected it to remain %q", actual, "/c")
+				}
+			})
+		}
+	})
+}
+
+func TestTriggerAnimated(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRequest()
+	if err := TriggerAnimated(r, "highlight", "#row-5"); err != nil {
+		t.Fatalf("TriggerAnimated returned error: %v", err)
+	}
+
+	resp := Response(r)
+	if actual := resp.TriggerAfterSettle["highlight"]; string(actual) != `"#row-5"` {
+		t.Errorf("TriggerAfterSettle[highlight] = %s, expected %s", actual, `"#row-5"`)
+	}
+	if len(resp.Trigger) > 0 || len(resp.TriggerAfterSwap) > 0 {
+		t.Errorf("expected only TriggerAfterSettle to be set, got Trigger %v and TriggerAfterSwap %v",
+			resp.Trigger, resp.TriggerAfterSwap)
+	}
+
+	if err := TriggerAnimated(r, "highlight", func() {}); err == nil {
+		t.Error("expected TriggerAnimated to return an error for invalid data")
+	}
+}
+
+func TestTriggerImmediatePostSwap(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRequest()
+	if err := TriggerImmediatePostSwap(r, "initChart", "#chart"); err != nil {
+		t.Fatalf("TriggerImmediatePostSwap returned error: %v", err)
+	}
+
+	resp := Response(r)
+	if actual := resp.TriggerAfterSwap["initChart"]; string(actual) != `"#chart"` {
+		t.Errorf("TriggerAfterSwap[initChart] = %s, expected %s", actual, `"#chart"`)
+	}
+	if len(resp.Trigger) > 0 || len(resp.TriggerAfterSettle) > 0 {
+		t.Errorf("expected only TriggerAfterSwap to be set, got Trigger %v and TriggerAfterSettle %v",
+			resp.Trigger, resp.TriggerAfterSettle)
+	}
+
+	if err := TriggerImmediatePostSwap(r, "initChart", func() {}); err == nil {
+		t.Error("expected TriggerImmediatePostSwap to return an error for invalid data")
+	}
+}
+
+func TestTriggerFields(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	u := user{ID: 5, Name: "abc", Email: "abc@example.com", Password: "secret"}
+
+	successCases := []struct {
+		name   string
+		v      any
+		fields []string
+		expect string
+	}{
+		{name: "subset", v: u, fields: []string{"id", "name"}, expect: `{"userUpdated":{"id":5,"name":"abc"}}`},
+		{name: "missing field", v: u, fields: []string{"id", "avatar"}, expect: `{"userUpdated":{"id":5}}`},
+		{name: "no fields", v: u, expect: `{"userUpdated":{}}`},
+		{name: "map", v: map[string]int{"a": 1, "b": 2}, fields: []string{"b"}, expect: `{"userUpdated":{"b":2}}`},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := TriggerFields(r, "userUpdated", c.v, c.fields...); err != nil {
+					t.Fatalf("TriggerFields returned error: %v", err)
+				}
+
+				if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+					t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name string
+		v    any
+	}{
+		{name: "not an object", v: []int{1}},
+		{name: "string", v: "abc"},
+		{name: "invalid value", v: func() {}},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := TriggerFields(r, "userUpdated", c.v, "id"); err == nil {
+					t.Fatal("expected TriggerFields to return an error")
+				}
+
+				if h := headers(r); len(h) > 0 {
+					t.Errorf("expected no headers to be set, got %v", h)
+				}
+			})
+		}
+	})
+}
+
+func TestTriggerAppend(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    func(r *http.Request)
+		expect string
+	}{
+		{
+			name:   "single",
+			set:    func(r *http.Request) { _ = TriggerAppend(r, "toast", 1) },
+			expect: `{"toast":[1]}`,
+		},
+		{
+			name: "multiple",
+			set: func(r *http.Request) {
+				_ = TriggerAppend(r, "toast", 1)
+				_ = TriggerAppend(r, "toast", "a")
+				_ = TriggerAppend(r, "toast", map[string]int{"b": 2})
+			},
+			expect: `{"toast":[1,"a",{"b":2}]}`,
+		},
+		{
+			name:   "nil",
+			set:    func(r *http.Request) { _ = TriggerAppend(r, "toast", nil) },
+			expect: `{"toast":[null]}`,
+		},
+		{
+			name: "after trigger",
+			set: func(r *http.Request) {
+				_ = Trigger(r, "toast", 1)
+				_ = TriggerAppend(r, "toast", 2)
+			},
+			expect: `{"toast":[1,2]}`,
+		},
+		{
+			name: "after trigger without data",
+			set: func(r *http.Request) {
+				TriggerEvent(r, "toast")
+				_ = TriggerAppend(r, "toast", 2)
+			},
+			expect: `{"toast":[null,2]}`,
+		},
+		{
+			name: "after overwriting trigger",
+			set: func(r *http.Request) {
+				_ = TriggerAppend(r, "toast", 1)
+				_ = Trigger(r, "toast", []int{2, 3})
+				_ = TriggerAppend(r, "toast", 4)
+			},
+			expect: `{"toast":[[2,3],4]}`,
+		},
+		{
+			name: "other events",
+			set: func(r *http.Request) {
+				_ = TriggerAppend(r, "toast", 1)
+				TriggerEvent(r, "reload")
+			},
+			expect: `{"reload":null,"toast":[1]}`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			c.set(r)
+
+			if actual := headers(r).Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+
+	t.Run("invalid data", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		_ = TriggerAppend(r, "toast", 1)
+		if err := TriggerAppend(r, "toast", func() {}); err == nil {
+			t.Fatal("expected TriggerAppend to return an error")
+		}
+
+		if actual := headers(r).Get("HX-Trigger"); actual != `{"toast":[1]}` {
+			t.Errorf("HX-Trigger = %q, expected it to remain %q", actual, `{"toast":[1]}`)
+		}
+	})
+}
+
+func TestClearResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		calls int
+	}{
+		{name: "once", calls: 1},
+		{name: "repeatedly", calls: 3},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+				Retarget(r, "#main")
+				Reswap(r, SwapOuterHTML)
+				PushURL(r, "/a")
+				_ = Trigger(r, "a", 1)
+				_ = TriggerAfterSettle(r, "b", nil)
+				_ = TriggerAfterSwap(r, "c", nil)
+
+				for i := 0; i < c.calls; i++ {
+					ClearResponse(r)
+				}
+
+				if ResponseModified(r) {
+					t.Error("expected the response not to be modified after ClearResponse")
+				}
+
+				// setters must not panic after clearing
+				_ = Trigger(r, "error", "abc")
+				_ = TriggerAfterSettle(r, "d", nil)
+				_ = TriggerAfterSwap(r, "e", nil)
+				Retarget(r, "#errors")
+
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+
+			expect := http.Header{
+				"Hx-Retarget":             {"#errors"},
+				"Hx-Trigger":              {`{"error":"abc"}`},
+				"Hx-Trigger-After-Settle": {"d"},
+				"Hx-Trigger-After-Swap":   {"e"},
+			}
+			if actual := rec.Header(); !reflect.DeepEqual(actual, expect) {
+				t.Errorf("headers = %v, expected %v", actual, expect)
+			}
+		})
+	}
+}
+
+func TestPushURLChecked(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		u         SameOriginURL
+		expectErr bool
+	}{
+		{name: "relative", u: "/a?b=c"},
+		{name: "false", u: "false"},
+		{name: "absolute same origin", u: "http://example.com/a"},
+		{name: "cross-origin", u: "https://evil.com/a", expectErr: true},
+		{name: "scheme-relative", u: "//evil.com", expectErr: true},
+		{name: "backslash", u: `/\evil.com`, expectErr: true},
+		{name: "javascript", u: "javascript:alert(1)", expectErr: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			PushURL(r, "/previous")
+
+			err := PushURLChecked(r, c.u)
+			if c.expectErr != errors.Is(err, ErrCrossOrigin) {
+				t.Fatalf("PushURLChecked() = %v, expected error: %t", err, c.expectErr)
+			}
+
+			expect := c.u
+			if c.expectErr {
+				expect = "/previous"
+			}
+			if actual := headers(r).Get("HX-Push-Url"); actual != expect {
+				t.Errorf("HX-Push-Url = %q, expected %q", actual, expect)
+			}
+		})
+	}
+}
+
+func TestReplaceURLChecked(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		u         SameOriginURL
+		expectErr bool
+	}{
+		{name: "relative", u: "/a?b=c"},
+		{name: "false", u: "false"},
+		{name: "absolute same origin", u: "http://example.com/a"},
+		{name: "cross-origin", u: "https://evil.com/a", expectErr: true},
+		{name: "scheme-relative", u: "//evil.com", expectErr: true},
+		{name: "backslash", u: `/\evil.com`, expectErr: true},
+		{name: "javascript", u: "javascript:alert(1)", expectErr: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			ReplaceURL(r, "/previous")
+
+			err := ReplaceURLChecked(r, c.u)
+			if c.expectErr != errors.Is(err, ErrCrossOrigin) {
+				t.Fatalf("ReplaceURLChecked() = %v, expected error: %t", err, c.expectErr)
+			}
+
+			expect := c.u
+			if c.expectErr {
+				expect = "/previous"
+			}
+			if actual := headers(r).Get("HX-Replace-Url"); actual != expect {
+				t.Errorf("HX-Replace-Url = %q, expected %q", actual, expect)
+			}
+		})
+	}
+}
+
+func TestUntrigger(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		untrigger func(r *http.Request, name Event)
+		header    string
+	}{
+		{name: "Untrigger", untrigger: Untrigger, header: "HX-Trigger"},
+		{name: "UntriggerAfterSettle", untrigger: UntriggerAfterSettle, header: "HX-Trigger-After-Settle"},
+		{name: "UntriggerAfterSwap", untrigger: UntriggerAfterSwap, header: "HX-Trigger-After-Swap"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("triggered", func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				for _, trigger := range []func(*http.Request, Event, any) error{
+					Trigger, TriggerAfterSettle, TriggerAfterSwap,
+				} {
+					_ = trigger(r, "a", 1)
+					_ = trigger(r, "b", 2)
+				}
+
+				c.untrigger(r, "a")
+
+				h := headers(r)
+				for _, name := range []string{"HX-Trigger", "HX-Trigger-After-Settle", "HX-Trigger-After-Swap"} {
+					expect := `{"a":1,"b":2}`
+					if name == c.header {
+						expect = `{"b":2}`
+					}
+					if actual := h.Get(name); actual != expect {
+						t.Errorf("%s = %q, expected %q", name, actual, expect)
+					}
+				}
+			})
+			t.Run("not triggered", func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				c.untrigger(r, "a")
+
+				if actual := headers(r).Get(c.header); actual != "" {
+					t.Errorf("%s = %q, expected it to be absent", c.header, actual)
+				}
+			})
+		})
+	}
+
+	t.Run("append after untrigger", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		_ = TriggerAppend(r, "toast", 1)
+		Untrigger(r, "toast")
+		_ = TriggerAppend(r, "toast", 2)
+
+		if actual := headers(r).Get("HX-Trigger"); actual != `{"toast":[2]}` {
+			t.Errorf("HX-Trigger = %q, expected %q", actual, `{"toast":[2]}`)
+		}
+	})
+}
+
+func TestReswap(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		strategy []SwapStrategy
+		expect   []string
+	}{
+		{name: "unset", expect: nil},
+		{name: "none", strategy: []SwapStrategy{SwapNone}, expect: []string{"none"}},
+		{name: "empty", strategy: []SwapStrategy{""}, expect: nil},
+		{name: "overwrite", strategy: []SwapStrategy{SwapNone, SwapOuterHTML}, expect: []string{"outerHTML"}},
+		{name: "clear", strategy: []SwapStrategy{SwapNone, ""}, expect: nil},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			for _, s := range c.strategy {
+				Reswap(r, s)
+			}
+
+			if actual := headers(r).Values("HX-Reswap"); !reflect.DeepEqual(actual, c.expect) {
+				t.Errorf("HX-Reswap = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestResponseModified(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    func(r *http.Request)
+		expect bool
+	}{
+		{name: "unmodified", set: func(*http.Request) {}, expect: false},
+		{name: "status", set: func(r *http.Request) { SetStatus(r, http.StatusCreated) }, expect: false},
+		{name: "push url", set: func(r *http.Request) { PushURL(r, "/a") }, expect: true},
+		{name: "refresh", set: func(r *http.Request) { Refresh(r, true) }, expect: true},
+		{name: "reswap none", set: func(r *http.Request) { Reswap(r, SwapNone) }, expect: true},
+		{name: "location", set: func(r *http.Request) { _ = Location(r, LocationData{Path: "/a"}) }, expect: true},
+		{name: "trigger", set: func(r *http.Request) { TriggerEvent(r, "a") }, expect: true},
+		{
+			name:   "after settle trigger",
+			set:    func(r *http.Request) { _ = TriggerAfterSettle(r, "a", nil) },
+			expect: true,
+		},
+		{
+			name:   "after swap trigger",
+			set:    func(r *http.Request) { _ = TriggerAfterSwap(r, "a", nil) },
+			expect: true,
+		},
+		{
+			name: "untriggered",
+			set: func(r *http.Request) {
+				TriggerEvent(r, "a")
+				Untrigger(r, "a")
+			},
+			expect: false,
+		},
+		{name: "extra header", set: func(r *http.Request) { AddExtraHeader(r, "X-A", "b") }, expect: true},
+		{
+			name: "cleared",
+			set: func(r *http.Request) {
+				Retarget(r, "#a")
+				ClearResponse(r)
+			},
+			expect: false,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			c.set(r)
+
+			if actual := ResponseModified(r); actual != c.expect {
+				t.Errorf("ResponseModified() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+// countingMarshaler counts how often it was marshalled.
+type countingMarshaler struct{ n *int }
+
+func (m countingMarshaler) MarshalJSON() ([]byte, error) {
+	*m.n++
+	return []byte(`{"b":1}`), nil
+}
+
+func TestLocationValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		var n int
+		values, err := LocationValues(countingMarshaler{n: &n})
+		if err != nil {
+			t.Fatalf("LocationValues returned error: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			r := newTestRequest()
+			if err := Location(r, LocationData{Path: "/a", Values: values}); err != nil {
+				t.Fatalf("Location returned error: %v", err)
+			}
+
+			expect := `{"path":"/a","values":{"b":1}}`
+			if actual := headers(r).Get("HX-Location"); actual != expect {
+				t.Errorf("HX-Location = %q, expected %q", actual, expect)
+			}
+		}
+
+		if n != 1 {
+			t.Errorf("values were marshalled %d times, expected once", n)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		values, err := LocationValues(func() {})
+		if err == nil {
+			t.Fatal("expected LocationValues to return an error")
+		}
+		if values != nil {
+			t.Errorf("LocationValues() = %s, expected nil", values)
+		}
+	})
+}
+
+func TestTriggerEvent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    func(r *http.Request)
+		expect string
+	}{
+		{
+			name:   "single",
+			set:    func(r *http.Request) { TriggerEvent(r, "closeModal") },
+			expect: "closeModal",
+		},
+		{
+			name: "multiple",
+			set: func(r *http.Request) {
+				TriggerEvent(r, "closeModal")
+				TriggerEvent(r, "refreshTable")
+			},
+			expect: "closeModal,refreshTable",
+		},
+		{
+			name: "mixed with data",
+			set: func(r *http.Request) {
+				TriggerEvent(r, "closeModal")
+				_ = Trigger(r, "toast", "Saved")
+			},
+			expect: `{"closeModal":null,"toast":"Saved"}`,
+		},
+		{
+			name: "overwrite data",
+			set: func(r *http.Request) {
+				_ = Trigger(r, "closeModal", "a")
+				TriggerEvent(r, "closeModal")
+			},
+			expect: "closeModal",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			c.set(r)
+
+			actual := headers(r).Get("HX-Trigger")
+
+			// events without data are not ordered
+			if !strings.HasPrefix(actual, "{") {
+				events := strings.Split(actual, ",")
+				sort.Strings(events)
+				actual = strings.Join(events, ",")
+			}
+
+			if actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRedirectOnly(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRequest()
+	_ = Location(r, NewLocation("/a").WithTarget("#main"))
+	Reswap(r, SwapOuterHTML)
+	Retarget(r, "#main")
+	Reselect(r, "#content")
+	PushURL(r, "/a")
+	_ = Trigger(r, "toast", "Logged out")
+	_ = TriggerAfterSettle(r, "a", nil)
+	_ = TriggerAfterSwap(r, "b", nil)
+
+	RedirectOnly(r, "/login")
+
+	expect := http.Header{
+		"Hx-Redirect":             {"/login"},
+		"Hx-Push-Url":             {"/a"},
+		"Hx-Trigger":              {`{"toast":"Logged out"}`},
+		"Hx-Trigger-After-Settle": {"a"},
+		"Hx-Trigger-After-Swap":   {"b"},
+	}
+	if actual := headers(r); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("headers = %v, expected %v", actual, expect)
+	}
+}
+
+func TestRetargetChecked(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		set       func(r *http.Request, sel Selector) error
+		header    string
+		sel       Selector
+		expectErr bool
+	}{
+		{name: "Retarget valid", set: RetargetChecked, header: "HX-Retarget", sel: "#main"},
+		{name: "Retarget empty", set: RetargetChecked, header: "HX-Retarget", sel: " ", expectErr: true},
+		{name: "Retarget unbalanced", set: RetargetChecked, header: "HX-Retarget", sel: "a[", expectErr: true},
+		{name: "Reselect valid", set: ReselectChecked, header: "HX-Reselect", sel: "#main"},
+		{name: "Reselect empty", set: ReselectChecked, header: "HX-Reselect", sel: "", expectErr: true},
+		{name: "Reselect unbalanced", set: ReselectChecked, header: "HX-Reselect", sel: "a)", expectErr: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			Retarget(r, "#previous")
+			Reselect(r, "#previous")
+
+			if err := c.set(r, c.sel); (err != nil) != c.expectErr {
+				t.Fatalf("error = %v, expected error: %t", err, c.expectErr)
+			}
+
+			expect := c.sel
+			if c.expectErr {
+				expect = "#previous"
+			}
+			if actual := headers(r).Get(c.header); actual != expect {
+				t.Errorf("%s = %q, expected %q", c.header, actual, expect)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		d      Directive
+		expect http.Header
+	}{
+		{name: "empty", d: Directive{}, expect: http.Header{}},
+		{
+			name: "all",
+			d: Directive{
+				Retarget:           "#list",
+				Reswap:             SwapOuterHTML,
+				Reselect:           "#content",
+				PushURL:            "/a",
+				ReplaceURL:         "/b",
+				Redirect:           "/c",
+				Refresh:            true,
+				Trigger:            map[Event]any{"itemAdded": 5, "toast": "Added"},
+				TriggerAfterSettle: map[Event]any{"a": nil},
+				TriggerAfterSwap:   map[Event]any{"b": nil},
+			},
+			expect: http.Header{
+				"Hx-Retarget":             {"#list"},
+				"Hx-Reswap":               {"outerHTML"},
+				"Hx-Reselect":             {"#content"},
+				"Hx-Push-Url":             {"/a"},
+				"Hx-Replace-Url":          {"/b"},
+				"Hx-Redirect":             {"/c"},
+				"Hx-Refresh":              {"true"},
+				"Hx-Trigger":              {`{"itemAdded":5,"toast":"Added"}`},
+				"Hx-Trigger-After-Settle": {"a"},
+				"Hx-Trigger-After-Swap":   {"b"},
+			},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := Apply(r, c.d); err != nil {
+					t.Fatalf("Apply returned error: %v", err)
+				}
+
+				if actual := headers(r); !reflect.DeepEqual(actual, c.expect) {
+					t.Errorf("headers = %v, expected %v", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	t.Run("zero fields", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		Retarget(r, "#main")
+		Reswap(r, SwapNone)
+
+		if err := Apply(r, Directive{Reselect: "#content"}); err != nil {
+			t.Fatalf("Apply returned error: %v", err)
+		}
+
+		expect := http.Header{
+			"Hx-Retarget": {"#main"},
+			"Hx-Reswap":   {"none"},
+			"Hx-Reselect": {"#content"},
+		}
+		if actual := headers(r); !reflect.DeepEqual(actual, expect) {
+			t.Errorf("headers = %v, expected %v", actual, expect)
+		}
+	})
+
+	t.Run("invalid trigger data", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		err := Apply(r, Directive{
+			Retarget:         "#list",
+			Trigger:          map[Event]any{"c": func() {}, "a": func() {}, "b": 1},
+			TriggerAfterSwap: map[Event]any{"d": make(chan int)},
+		})
+		if err == nil {
+			t.Fatal("expected Apply to return an error")
+		}
+
+		lines := strings.Split(err.Error(), "\n")
+		expectPrefixes := []string{"HX-Trigger: a: ", "HX-Trigger: c: ", "HX-Trigger-After-Swap: d: "}
+		if len(lines) != len(expectPrefixes) {
+			t.Fatalf("error = %q, expected %d joined errors", err, len(expectPrefixes))
+		}
+		for i, prefix := range expectPrefixes {
+			if !strings.HasPrefix(lines[i], prefix) {
+				t.Errorf("error %d = %q, expected prefix %q", i, lines[i], prefix)
+			}
+		}
+
+		expect := http.Header{
+			"Hx-Retarget": {"#list"},
+			"Hx-Trigger":  {`{"b":1}`},
+		}
+		if actual := headers(r); !reflect.DeepEqual(actual, expect) {
+			t.Errorf("headers = %v, expected %v", actual, expect)
+		}
+	})
+}
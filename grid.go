@@ -0,0 +1,81 @@
+package htmx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of the headers read by [RequestHeaders.Grid].
+//
+// These are not part of htmx, and must be sent by the client, e.g. using
+// hx-headers.
+// Change them, if your application uses different names.
+var (
+	SortHeader   = "HX-Sort"
+	PageHeader   = "HX-Page"
+	FilterHeader = "HX-Filter"
+)
+
+// GridState is the state of a data grid, or similar composite widget, as
+// sent by the client.
+//
+// All fields are optional, and have their zero value if the corresponding
+// header is absent.
+type GridState struct {
+	// Sort is the name of the field to sort by.
+	Sort string
+	// SortDesc is true, if the grid is sorted in descending order.
+	SortDesc bool
+	// Page is the 1-based number of the requested page.
+	Page int
+	// Filter is the filter of the grid.
+	Filter string
+}
+
+// Grid returns the [GridState] sent using the [SortHeader], [PageHeader],
+// and [FilterHeader].
+//
+// The sort header must either contain only the name of the field to sort by,
+// in which case the sort order is ascending, or the name followed by ":asc"
+// or ":desc", e.g. "created:desc".
+// The page header must contain a positive integer.
+// The filter header is used as is.
+//
+// An error is returned, if one of the headers is invalid.
+// If h is nil, Grid returns the zero value.
+func (h *RequestHeaders) Grid() (GridState, error) {
+	var s GridState
+	if h == nil {
+		return s, nil
+	}
+
+	if sort := h.ClientHeader(SortHeader); sort != "" {
+		field, order, _ := strings.Cut(sort, ":")
+		if field == "" {
+			return s, fmt.Errorf("htmx: %s: missing field", SortHeader)
+		}
+
+		s.Sort = field
+		switch order {
+		case "", "asc":
+		case "desc":
+			s.SortDesc = true
+		default:
+			return s, fmt.Errorf("htmx: %s: invalid sort order %q", SortHeader, order)
+		}
+	}
+
+	if page := h.ClientHeader(PageHeader); page != "" {
+		var err error
+		s.Page, err = strconv.Atoi(page)
+		if err != nil {
+			return s, fmt.Errorf("htmx: %s: %w", PageHeader, err)
+		} else if s.Page <= 0 {
+			return s, fmt.Errorf("htmx: %s: page must be positive", PageHeader)
+		}
+	}
+
+	s.Filter = h.ClientHeader(FilterHeader)
+	return s, nil
+}
@@ -0,0 +1,98 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestHeaders_Grid(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		header http.Header
+		expect GridState
+	}{
+		{name: "nil", header: http.Header{"Hx-Sort": {"name"}}, expect: GridState{}},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}, expect: GridState{}},
+		{
+			name: "full",
+			header: http.Header{
+				"Hx-Request": {"true"},
+				"Hx-Sort":    {"created:desc"},
+				"Hx-Page":    {"3"},
+				"Hx-Filter":  {"status:open"},
+			},
+			expect: GridState{Sort: "created", SortDesc: true, Page: 3, Filter: "status:open"},
+		},
+		{
+			name:   "sort without order",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Sort": {"name"}},
+			expect: GridState{Sort: "name"},
+		},
+		{
+			name:   "sort ascending",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Sort": {"name:asc"}},
+			expect: GridState{Sort: "name"},
+		},
+		{
+			name:   "page only",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Page": {"2"}},
+			expect: GridState{Page: 2},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header = c.header
+
+				actual, err := Request(r).Grid()
+				if err != nil {
+					t.Fatalf("Grid returned error: %v", err)
+				}
+
+				if actual != c.expect {
+					t.Errorf("Grid() = %+v, expected %+v", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name   string
+		header http.Header
+	}{
+		{name: "missing sort field", header: http.Header{"Hx-Sort": {":desc"}}},
+		{name: "invalid sort order", header: http.Header{"Hx-Sort": {"name:up"}}},
+		{name: "invalid page", header: http.Header{"Hx-Page": {"abc"}}},
+		{name: "zero page", header: http.Header{"Hx-Page": {"0"}}},
+		{name: "negative page", header: http.Header{"Hx-Page": {"-1"}}},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header = c.header
+				r.Header.Set("HX-Request", "true")
+
+				if _, err := Request(r).Grid(); err == nil {
+					t.Error("expected Grid to return an error")
+				}
+			})
+		}
+	})
+}
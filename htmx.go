@@ -1,6 +1,9 @@
 package htmx
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // Aliases for easier understanding of fields.
 type (
@@ -22,6 +25,7 @@ type SwapStrategy string
 const (
 	SwapInnerHTML   SwapStrategy = "innerHTML"
 	SwapOuterHTML   SwapStrategy = "outerHTML"
+	SwapTextContent SwapStrategy = "textContent"
 	SwapBeforeBegin SwapStrategy = "beforebegin"
 	SwapAfterBegin  SwapStrategy = "afterbegin"
 	SwapBeforeEnd   SwapStrategy = "beforeend"
@@ -29,3 +33,27 @@ const (
 	SwapDelete      SwapStrategy = "delete"
 	SwapNone        SwapStrategy = "none"
 )
+
+// valid reports whether the style of s is well-formed, or s only consists
+// of modifiers.
+//
+// Since extensions may define their own swap styles, any style consisting
+// only of letters, digits, dashes, and underscores is considered valid, not
+// just the known ones.
+// Modifiers are not validated.
+func (s SwapStrategy) valid() bool {
+	style, _, _ := strings.Cut(strings.TrimSpace(string(s)), " ")
+	if style == "" {
+		return false
+	} else if strings.Contains(style, ":") {
+		return true
+	}
+
+	for _, c := range style {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+
+	return true
+}
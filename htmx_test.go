@@ -0,0 +1,42 @@
+package htmx
+
+import "testing"
+
+func TestSwapStrategy_valid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		s      SwapStrategy
+		expect bool
+	}{
+		{s: SwapInnerHTML, expect: true},
+		{s: SwapOuterHTML, expect: true},
+		{s: SwapTextContent, expect: true},
+		{s: SwapBeforeBegin, expect: true},
+		{s: SwapAfterBegin, expect: true},
+		{s: SwapBeforeEnd, expect: true},
+		{s: SwapAfterEnd, expect: true},
+		{s: SwapDelete, expect: true},
+		{s: SwapNone, expect: true},
+		{s: "morph", expect: true},
+		{s: "morph:outerHTML", expect: true},
+		{s: "innerHTML swap:1s settle:2s", expect: true},
+		{s: "swap:1s", expect: true},
+		{s: "", expect: false},
+		{s: "   ", expect: false},
+		{s: "inner<HTML>", expect: false},
+		{s: "innerHTML,", expect: false},
+		{s: "'innerHTML'", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(string(c.s), func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.s.valid(); actual != c.expect {
+				t.Errorf("%q.valid() = %t, expected %t", c.s, actual, c.expect)
+			}
+		})
+	}
+}
@@ -2,58 +2,563 @@ package htmx
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 )
 
-type ctxKey struct{}
+type (
+	ctxKey        struct{}
+	requestCtxKey struct{}
+	bypassCtxKey  struct{}
+)
+
+// ErrTriggerTooLarge is the warning reported in strict mode, if a trigger
+// header exceeds the size set using [WithMaxTriggerSize].
+var ErrTriggerTooLarge = errors.New("htmx: trigger header exceeds maximum size")
+
+// ErrBodyAfterRefresh is the warning reported in strict mode, if a body is
+// written, although HX-Refresh is set.
+//
+// Since htmx reloads the page if HX-Refresh is set, the body is never used.
+var ErrBodyAfterRefresh = errors.New("htmx: body written although HX-Refresh is set")
 
 type responseWriterWrapper struct {
 	http.ResponseWriter
+	r            *http.Request
 	h            *ResponseHeaders
+	o            *middlewareOptions
 	wroteHeaders bool
+	wroteStatus  bool
+	warnedBody   bool
+}
+
+func newResponseWriterWrapper(
+	w http.ResponseWriter, r *http.Request, h *ResponseHeaders, o *middlewareOptions,
+) *responseWriterWrapper {
+	return &responseWriterWrapper{ResponseWriter: w, r: r, h: h, o: o}
 }
 
 func (w *responseWriterWrapper) Write(data []byte) (int, error) {
-	w.writeHXHeader()
+	w.writeStatus()
+
+	if w.o.strict != nil && !w.warnedBody && len(data) > 0 && w.h.Refresh {
+		w.warnedBody = true
+		w.o.strict(w.r, ErrBodyAfterRefresh)
+	}
+
 	return w.ResponseWriter.Write(data)
 }
 
 func (w *responseWriterWrapper) WriteHeader(statusCode int) {
+	w.wroteStatus = true
 	w.writeHXHeader()
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// FlushError writes the status set using [SetStatus] and the htmx headers,
+// if that hasn't already happened, and then flushes the wrapped
+// [http.ResponseWriter].
+func (w *responseWriterWrapper) FlushError() error {
+	w.writeStatus()
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// writeStatus writes the status set using [SetStatus] and the htmx headers,
+// if that hasn't already happened.
+//
+// Afterwards, the status is considered written, since the wrapped
+// [http.ResponseWriter] implicitly writes a 200 status when writing the body
+// or flushing.
+func (w *responseWriterWrapper) writeStatus() {
+	if !w.wroteStatus && w.h.status != 0 {
+		w.WriteHeader(w.h.status)
+	}
+	w.wroteStatus = true
+	w.writeHXHeader()
+}
+
+// Unwrap returns the wrapped [http.ResponseWriter], allowing
+// [http.ResponseController] to access its optional methods, such as Flush.
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *responseWriterWrapper) writeHXHeader() {
 	if w.wroteHeaders {
 		return
 	}
-
-	w.h.AddHeaders(w.ResponseWriter.Header())
 	w.wroteHeaders = true
+
+	rh := w.h
+	if w.o.sameOrigin {
+		rh = w.sameOriginHeaders()
+	}
+
+	h := make(http.Header)
+	rh.AddHeaders(h)
+
+	if w.o.maxTriggerSize > 0 {
+		w.limitTriggerSize(h)
+	}
+
+	for _, name := range w.o.disabled {
+		h.Del(name)
+	}
+
+	dst := w.ResponseWriter.Header()
+	for name, vals := range h {
+		dst[name] = append(dst[name], vals...)
+	}
+
+	if w.o.audit != nil {
+		emitted := make([]string, 0, len(h))
+		for name := range h {
+			emitted = append(emitted, name)
+		}
+		sort.Strings(emitted)
+
+		w.o.audit(w.r, emitted)
+	}
+}
+
+// limitTriggerSize replaces or removes the trigger headers in h that exceed
+// the maximum trigger size.
+func (w *responseWriterWrapper) limitTriggerSize(h http.Header) {
+	for _, name := range [...]string{"HX-Trigger", "HX-Trigger-After-Settle", "HX-Trigger-After-Swap"} {
+		val := h.Get(name)
+		if len(val) <= w.o.maxTriggerSize {
+			continue
+		}
+
+		if w.o.strict != nil {
+			w.o.strict(w.r, fmt.Errorf("htmx: %s: %d bytes: %w", name, len(val), ErrTriggerTooLarge))
+		}
+
+		if w.o.triggerFallback != "" {
+			h.Set(name, w.o.triggerFallback)
+		} else {
+			h.Del(name)
+		}
+	}
+}
+
+// sameOriginHeaders returns a copy of the response headers, with all URLs
+// that are not same-origin removed.
+func (w *responseWriterWrapper) sameOriginHeaders() *ResponseHeaders {
+	h := *w.h
+
+	check := func(name string, u *string) {
+		if *u == "" || sameOrigin(w.r, *u) {
+			return
+		}
+
+		if w.o.onCrossOrigin != nil {
+			w.o.onCrossOrigin(w.r, fmt.Errorf("htmx: %s: %q: %w", name, *u, ErrCrossOrigin))
+		}
+		*u = ""
+	}
+
+	if !h.externalRedirect {
+		check("HX-Redirect", &h.Redirect)
+	}
+	check("HX-Location", &h.Location.Path)
+	check("HX-Push-Url", &h.PushURL)
+	check("HX-Replace-Url", &h.ReplaceURL)
+
+	return &h
+}
+
+type (
+	// Middleware is the type of the middleware returned by [NewMiddleware].
+	//
+	// It is an alias for the signature expected by most routers, such as chi,
+	// so it can be passed to their Use methods directly.
+	Middleware = func(next http.Handler) http.Handler
+
+	// MiddlewareOption is an option used to configure the middleware
+	// returned by [NewMiddleware].
+	MiddlewareOption func(*middlewareOptions)
+
+	middlewareOptions struct {
+		disabled []string
+
+		deprecationEvent Event
+		deprecationData  JSON
+
+		strict func(r *http.Request, err error)
+
+		sameOrigin    bool
+		onCrossOrigin func(r *http.Request, err error)
+
+		cacheRequest bool
+
+		maxTriggerSize  int
+		triggerFallback Event
+
+		audit func(r *http.Request, emitted []string)
+
+		bypass func(r *http.Request) bool
+
+		htmxOnly bool
+
+		vary []string
+	}
+)
+
+// WithDisabled disables the htmx response headers with the passed names,
+// e.g. "HX-Redirect" or "HX-Refresh".
+//
+// Handlers may still call the setters of those headers, but the middleware
+// won't include them in the response.
+//
+// This is useful, if the same handlers are used in environments in which
+// certain headers must never be sent.
+// For example, when serving content embedded in an iframe, you might want
+// to prevent top-level redirects by disabling HX-Redirect, HX-Location,
+// and HX-Refresh for those routes.
+func WithDisabled(names ...string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.disabled = append(o.disabled, names...)
+	}
+}
+
+// WithDeprecation marks the routes handled by the middleware as deprecated.
+//
+// For every htmx request, the middleware will trigger the passed event with
+// the deprecation message as data, so that a client-side listener can
+// surface it, e.g. in development builds:
+//
+//	document.body.addEventListener("deprecated", (evt) => {
+//	    console.warn(evt.detail.value);
+//	});
+//
+// Non-htmx requests are not affected.
+//
+// This is useful when incrementally migrating endpoints.
+// To toggle the notification, e.g. to only enable it in development, only
+// pass this option if desired.
+func WithDeprecation(event Event, message string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.deprecationEvent = event
+		o.deprecationData, _ = json.Marshal(message) // strings never fail
+	}
+}
+
+// WithStrict enables strict mode, in which the middleware checks for common
+// mistakes and reports them as warnings by calling warn.
+//
+// Currently, the following mistakes are detected:
+//
+//   - writing a body, although HX-Refresh is set ([ErrBodyAfterRefresh])
+//   - trigger headers exceeding the maximum size set using
+//     [WithMaxTriggerSize] ([ErrTriggerTooLarge])
+//
+// Strict mode is intended for development, and should not be enabled in
+// production.
+func WithStrict(warn func(r *http.Request, err error)) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.strict = warn
+	}
+}
+
+// WithSameOriginURLs ensures that the URLs in the HX-Redirect, HX-Location,
+// HX-Push-Url, and HX-Replace-Url headers are of the same origin as the
+// request, preventing open redirects.
+//
+// Headers with URLs of another origin are dropped.
+// If onCrossOrigin is not nil, it is called with an error wrapping
+// [ErrCrossOrigin] for every dropped header, e.g. to log it or to fail
+// tests.
+//
+// Relative URLs are always allowed.
+// Absolute URLs are allowed, if they use http or https and have the same host
+// as the request.
+// Their scheme isn't compared, since TLS is usually terminated by a proxy.
+// Redirects that are intentionally external must be set using
+// [ExternalRedirect] instead of [Redirect].
+func WithSameOriginURLs(onCrossOrigin func(r *http.Request, err error)) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.sameOrigin = true
+		o.onCrossOrigin = onCrossOrigin
+	}
+}
+
+// WithRequestCache makes the middleware store a cache for the htmx request
+// headers in the request's context, so that [RequestCached] only parses them
+// once.
+//
+// The headers are parsed lazily, on the first call to RequestCached, so
+// requests that never access them don't pay for parsing.
+//
+// This is useful, if the request headers are accessed repeatedly.
+func WithRequestCache() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.cacheRequest = true
+	}
+}
+
+// WithMaxTriggerSize limits the size of the HX-Trigger,
+// HX-Trigger-After-Settle, and HX-Trigger-After-Swap headers to limit bytes.
+//
+// Servers and proxies limit the size of headers, and may drop headers or
+// reject responses exceeding that limit, which breaks the client in ways
+// that are hard to diagnose.
+//
+// If a trigger header exceeds the limit, it is replaced by the fallback
+// event, which can tell the client to refetch the data that was too large to
+// send.
+// If fallback is empty, the header is dropped instead.
+// In strict mode, a warning wrapping [ErrTriggerTooLarge] is reported as
+// well.
+func WithMaxTriggerSize(limit int, fallback Event) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.maxTriggerSize = limit
+		o.triggerFallback = fallback
+	}
+}
+
+// WithAudit calls audit with the names of the htmx headers, and the extra
+// headers in [ResponseHeaders.Extra], written to the response, e.g. to keep
+// an audit log of client-side redirects.
+//
+// Unlike inspecting [Response], this reports the headers that were actually
+// written, i.e. after headers were removed by other options, such as
+// [WithDisabled] or [WithSameOriginURLs].
+//
+// The names are in their canonical form, e.g. "Hx-Redirect", and sorted.
+// audit is called once per request, even if no headers were written.
+func WithAudit(audit func(r *http.Request, emitted []string)) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.audit = audit
+	}
+}
+
+// WithBypass makes the middleware bypass all requests for which bypass
+// reports true, e.g. health checks, webhooks, or API calls.
+//
+// Bypassed requests are passed to the next handler as is, without wrapping
+// the response writer, and all other options are ignored for them.
+// Additionally, they are never considered htmx requests, i.e. [Request]
+// returns nil, even if the client sent htmx headers.
+// Setters remain safe to call, but have no effect, just as if the
+// middleware wasn't used.
+func WithBypass(bypass func(r *http.Request) bool) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.bypass = bypass
+	}
+}
+
+// WithHTMXOnly makes the middleware only handle htmx requests, i.e. requests
+// for which [IsHTMX] reports true.
+//
+// Other requests are passed to the next handler without allocating response
+// headers or wrapping the response writer, which avoids the overhead of the
+// middleware on routes mostly serving full page loads.
+// Handlers can stay the same, as setters remain safe to call for these
+// requests, but, just as if the middleware wasn't used, have no effect.
+// Setters that don't marshal data don't allocate either.
+// This also includes [SetStatus].
+//
+// The Vary header is still added, if [WithVaryHeaders] is used.
+func WithHTMXOnly() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.htmxOnly = true
+	}
+}
+
+// WithVaryHeaders makes the middleware add the passed header names to the
+// Vary header of every response.
+// If no names are passed, "HX-Request" is used.
+//
+// This is necessary, if the same URL serves both full pages and fragments,
+// depending on whether the request was made by htmx.
+// Otherwise, caches might serve a fragment in response to a regular request,
+// or vice versa.
+// If responses also depend on other htmx headers, such as HX-Target, add
+// those as well.
+//
+// The names are appended to an existing Vary header, and names already
+// present are not added again.
+func WithVaryHeaders(names ...string) MiddlewareOption {
+	if len(names) == 0 {
+		names = []string{"HX-Request"}
+	}
+
+	return func(o *middlewareOptions) {
+		o.vary = append(o.vary, names...)
+	}
 }
 
 // NewMiddleware returns a new middleware that adds htmx headers, set by
 // handlers called after this middleware, to the response.
-func NewMiddleware() func(next http.Handler) http.Handler {
+//
+// The middleware stores the response headers in the context of the request,
+// and replaces the request in place, so that frameworks holding on to the
+// original *http.Request, such as Echo, see the headers as well.
+// For Echo, use echo.WrapMiddleware to convert the middleware:
+//
+//	e.Use(echo.WrapMiddleware(htmx.NewMiddleware()))
+//
+// Within Echo handlers, use c.Request() to access the headers, e.g.
+// htmx.Retarget(c.Request(), "#main").
+func NewMiddleware(opts ...MiddlewareOption) Middleware {
+	var o middlewareOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			h := ResponseHeaders{
-				Trigger:            make(map[Event]JSON),
-				TriggerAfterSettle: make(map[Event]JSON),
-				TriggerAfterSwap:   make(map[Event]JSON),
+			if o.bypass != nil && o.bypass(r) {
+				*r = *r.WithContext(context.WithValue(r.Context(), bypassCtxKey{}, true))
+				next.ServeHTTP(w, r)
+				return
 			}
-			*r = *r.WithContext(context.WithValue(r.Context(), ctxKey{}, &h))
 
-			ww := &responseWriterWrapper{ResponseWriter: w, h: &h}
+			if len(o.vary) > 0 {
+				addVary(w.Header(), o.vary)
+			}
+
+			if o.htmxOnly && !IsHTMX(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := newResponseHeaders()
+			ctx := context.WithValue(r.Context(), ctxKey{}, h)
+			if o.cacheRequest {
+				ctx = context.WithValue(ctx, requestCtxKey{}, new(cachedRequest))
+			}
+			*r = *r.WithContext(ctx)
+
+			if o.deprecationEvent != "" && IsHTMX(r) {
+				h.Trigger[o.deprecationEvent] = o.deprecationData
+			}
+
+			ww := newResponseWriterWrapper(w, r, h, &o)
 			next.ServeHTTP(ww, r)
+			if !ww.wroteStatus && h.status != 0 {
+				ww.WriteHeader(h.status)
+			}
 			ww.writeHXHeader()
 		})
 	}
 }
 
+// addVary adds the passed names to the Vary header in h, skipping names
+// already present.
+func addVary(h http.Header, names []string) {
+	var vary []string
+	for _, val := range h.Values("Vary") {
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				vary = append(vary, name)
+			}
+		}
+	}
+
+	n := len(vary)
+	for _, name := range names {
+		present := slices.ContainsFunc(vary, func(existing string) bool {
+			return existing == "*" || strings.EqualFold(existing, name)
+		})
+		if !present {
+			vary = append(vary, name)
+		}
+	}
+
+	if len(vary) > n {
+		h.Set("Vary", strings.Join(vary, ", "))
+	}
+}
+
 // Response returns a pointer to the response headers that will be sent back.
 //
 // It must be called after the middleware has executed.
+// Otherwise, Response returns detached response headers that are never
+// sent.
+// This means that all setters are safe to call on any request, but are
+// effectively no-ops, if the middleware didn't handle the request.
 func Response(r *http.Request) *ResponseHeaders {
-	return r.Context().Value(ctxKey{}).(*ResponseHeaders)
+	h, _ := ResponseOK(r)
+	return h
+}
+
+// ResponseOK is the same as [Response], but additionally reports whether the
+// returned response headers will be sent, i.e. whether the middleware
+// handles the request.
+//
+// This is useful for code that is also invoked outside the middleware, e.g.
+// in background jobs or tests, and behaves differently if its headers are
+// discarded.
+func ResponseOK(r *http.Request) (*ResponseHeaders, bool) {
+	if h := attachedResponse(r); h != nil {
+		return h, true
+	}
+
+	return newResponseHeaders(), false
+}
+
+// attachedResponse returns the response headers attached to r by the
+// middleware, or nil, if the middleware didn't handle r.
+//
+// Setters use it instead of [Response], so that they are no-ops without
+// allocating detached response headers, that would be discarded anyway.
+func attachedResponse(r *http.Request) *ResponseHeaders {
+	h, _ := r.Context().Value(ctxKey{}).(*ResponseHeaders)
+	return h
+}
+
+// ForwardTo calls next to handle r, while guaranteeing that the response
+// headers of r are shared with next.
+//
+// Since the response headers are stored in the request's context, handlers
+// called by a handler share the same response headers by default.
+// Triggers set by next are therefore merged into the response of the outer
+// handler.
+// However, if next replaces the context of r with one not derived from the
+// original context, the response headers would be lost for code running
+// after next.
+// ForwardTo guards against this, by re-attaching the response headers to r
+// after next returns.
+func ForwardTo(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	h, ok := r.Context().Value(ctxKey{}).(*ResponseHeaders)
+	next.ServeHTTP(w, r)
+
+	if ok && r.Context().Value(ctxKey{}) != h {
+		*r = *r.WithContext(context.WithValue(r.Context(), ctxKey{}, h))
+	}
+}
+
+// cachedRequest is the cache for the request headers used by
+// [RequestCached].
+type cachedRequest struct {
+	once sync.Once
+	h    *RequestHeaders
+}
+
+// RequestCached is the same as [Request], but caches the request headers, if
+// the middleware was created using [WithRequestCache].
+//
+// The headers are parsed on the first call to RequestCached, and subsequent
+// calls return the same [RequestHeaders].
+// Hence, changes made to the headers of r after the first call are not
+// reflected.
+// RequestCached is safe for concurrent use.
+//
+// If the middleware doesn't cache the headers, RequestCached falls back to
+// parsing them using Request.
+func RequestCached(r *http.Request) *RequestHeaders {
+	if c, ok := r.Context().Value(requestCtxKey{}).(*cachedRequest); ok {
+		c.once.Do(func() { c.h = Request(r) })
+		return c.h
+	}
+
+	return Request(r)
 }
@@ -0,0 +1,988 @@
+package htmx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serve serves r using h wrapped in the passed middleware, and returns the
+// recorded response.
+func serve(mw Middleware, r *http.Request, h http.HandlerFunc) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mw(h).ServeHTTP(rec, r)
+	return rec
+}
+
+// newTestRequest creates a new request with response headers attached, as
+// if it was handled by the middleware.
+func newTestRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	return r.WithContext(context.WithValue(r.Context(), ctxKey{}, newResponseHeaders()))
+}
+
+// headers returns the htmx headers set for r.
+func headers(r *http.Request) http.Header {
+	h := make(http.Header)
+	Response(r).AddHeaders(h)
+	return h
+}
+
+func TestWithSameOriginURLs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    func(r *http.Request)
+		header string
+		expect string
+	}{
+		{
+			name:   "same-origin redirect",
+			set:    func(r *http.Request) { Redirect(r, "/a") },
+			header: "HX-Redirect",
+			expect: "/a",
+		},
+		{
+			name:   "same-host https redirect",
+			set:    func(r *http.Request) { Redirect(r, "https://example.com/a") },
+			header: "HX-Redirect",
+			expect: "https://example.com/a",
+		},
+		{
+			name:   "cross-origin redirect",
+			set:    func(r *http.Request) { Redirect(r, "https://evil.com") },
+			header: "HX-Redirect",
+		},
+		{
+			name:   "scheme-relative redirect",
+			set:    func(r *http.Request) { Redirect(r, "//evil.com") },
+			header: "HX-Redirect",
+		},
+		{
+			name:   "backslash redirect",
+			set:    func(r *http.Request) { Redirect(r, `/\evil.com`) },
+			header: "HX-Redirect",
+		},
+		{
+			name:   "javascript redirect",
+			set:    func(r *http.Request) { Redirect(r, "javascript:alert(1)") },
+			header: "HX-Redirect",
+		},
+		{
+			name:   "external redirect",
+			set:    func(r *http.Request) { ExternalRedirect(r, "https://example.org") },
+			header: "HX-Redirect",
+			expect: "https://example.org",
+		},
+		{
+			name:   "cross-origin location",
+			set:    func(r *http.Request) { LocationPath(r, "https://evil.com") },
+			header: "HX-Location",
+		},
+		{
+			name:   "backslash push url",
+			set:    func(r *http.Request) { PushURL(r, `/\evil.com`) },
+			header: "HX-Push-Url",
+		},
+		{
+			name:   "backslash replace url",
+			set:    func(r *http.Request) { ReplaceURL(r, `/\evil.com`) },
+			header: "HX-Replace-Url",
+		},
+		{
+			name:   "same-origin replace url",
+			set:    func(r *http.Request) { ReplaceURL(r, "/a") },
+			header: "HX-Replace-Url",
+			expect: "/a",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var crossOriginErr error
+			mw := NewMiddleware(WithSameOriginURLs(func(_ *http.Request, err error) {
+				crossOriginErr = err
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			rec := serve(mw, r, func(w http.ResponseWriter, r *http.Request) {
+				c.set(r)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			if actual := rec.Header().Get(c.header); actual != c.expect {
+				t.Errorf("%s = %q, expected %q", c.header, actual, c.expect)
+			}
+
+			if c.expect == "" && !errors.Is(crossOriginErr, ErrCrossOrigin) {
+				t.Errorf("expected onCrossOrigin to be called with ErrCrossOrigin, got %v", crossOriginErr)
+			} else if c.expect != "" && crossOriginErr != nil {
+				t.Errorf("expected onCrossOrigin not to be called, got %v", crossOriginErr)
+			}
+		})
+	}
+}
+
+func TestRequestCached(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		opts   []MiddlewareOption
+		header http.Header
+		cached bool
+	}{
+		{
+			name:   "htmx request",
+			opts:   []MiddlewareOption{WithRequestCache()},
+			header: http.Header{"Hx-Request": {"true"}},
+			cached: true,
+		},
+		{
+			name:   "non-htmx request",
+			opts:   []MiddlewareOption{WithRequestCache()},
+			header: http.Header{},
+			cached: true,
+		},
+		{
+			name:   "no cache",
+			header: http.Header{"Hx-Request": {"true"}},
+			cached: false,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			serve(NewMiddleware(c.opts...), r, func(_ http.ResponseWriter, r *http.Request) {
+				// headers are parsed lazily
+				r.Header.Set("HX-Target", "main")
+
+				var wg sync.WaitGroup
+				results := make([]*RequestHeaders, 4)
+				for i := range results {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						results[i] = RequestCached(r)
+					}(i)
+				}
+				wg.Wait()
+
+				expect := Request(r)
+				for _, actual := range results {
+					if !reflect.DeepEqual(actual, expect) {
+						t.Errorf("RequestCached(r) = %+v, expected %+v", actual, expect)
+					}
+					if c.cached && actual != results[0] {
+						t.Error("expected all calls to return the same cached headers")
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRequestCached(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Target", "main")
+	r.Header.Set("HX-Current-URL", "http://example.com/a")
+
+	b.Run("cached", func(b *testing.B) {
+		serve(NewMiddleware(WithRequestCache()), r.Clone(r.Context()), func(_ http.ResponseWriter, r *http.Request) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = RequestCached(r)
+			}
+		})
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		serve(NewMiddleware(), r.Clone(r.Context()), func(_ http.ResponseWriter, r *http.Request) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = RequestCached(r)
+			}
+		})
+	})
+}
+
+func TestWithDisabled(t *testing.T) {
+	t.Parallel()
+
+	mw := NewMiddleware(WithDisabled("HX-Redirect", "hx-refresh"))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := serve(mw, r, func(w http.ResponseWriter, r *http.Request) {
+		Redirect(r, "/a")
+		Refresh(r, true)
+		Retarget(r, "#main")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, name := range []string{"HX-Redirect", "HX-Refresh"} {
+		if actual := rec.Header().Get(name); actual != "" {
+			t.Errorf("%s = %q, expected it to be disabled", name, actual)
+		}
+	}
+
+	if actual := rec.Header().Get("HX-Retarget"); actual != "#main" {
+		t.Errorf("HX-Retarget = %q, expected %q", actual, "#main")
+	}
+}
+
+func TestWithDeprecation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		htmx   bool
+		expect string
+	}{
+		{name: "htmx request", htmx: true, expect: `{"deprecated":"use /v2/items"}`},
+		{name: "non-htmx request", htmx: false, expect: ""},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.htmx {
+				r.Header.Set("HX-Request", "true")
+			}
+
+			mw := NewMiddleware(WithDeprecation("deprecated", "use /v2/items"))
+			rec := serve(mw, r, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			if actual := rec.Header().Get("HX-Trigger"); actual != c.expect {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestWithStrict(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		write  func(w http.ResponseWriter, r *http.Request)
+		expect []error
+	}{
+		{
+			name: "body after refresh",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				Refresh(r, true)
+				_, _ = w.Write([]byte("a"))
+				_, _ = w.Write([]byte("b"))
+			},
+			expect: []error{ErrBodyAfterRefresh},
+		},
+		{
+			name: "refresh without body",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				Refresh(r, true)
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		{
+			name: "body without refresh",
+			write: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("a"))
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var warnings []error
+			mw := NewMiddleware(WithStrict(func(_ *http.Request, err error) {
+				warnings = append(warnings, err)
+			}))
+
+			serve(mw, httptest.NewRequest(http.MethodGet, "/", nil), c.write)
+
+			if len(warnings) != len(c.expect) {
+				t.Fatalf("warnings = %v, expected %v", warnings, c.expect)
+			}
+			for i, expect := range c.expect {
+				if !errors.Is(warnings[i], expect) {
+					t.Errorf("warnings[%d] = %v, expected %v", i, warnings[i], expect)
+				}
+			}
+		})
+	}
+}
+
+func TestWithMaxTriggerSize(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("a", 100)
+
+	testCases := []struct {
+		name                string
+		fallback            Event
+		set                 func(r *http.Request)
+		expectTrigger       string
+		expectAfterSettle   string
+		expectWarningsCount int
+	}{
+		{
+			name:          "within limit",
+			fallback:      "refetch",
+			set:           func(r *http.Request) { _ = Trigger(r, "a", "b") },
+			expectTrigger: `{"a":"b"}`,
+		},
+		{
+			name:                "oversized with fallback",
+			fallback:            "refetch",
+			set:                 func(r *http.Request) { _ = Trigger(r, "a", large) },
+			expectTrigger:       "refetch",
+			expectWarningsCount: 1,
+		},
+		{
+			name:                "oversized without fallback",
+			set:                 func(r *http.Request) { _ = Trigger(r, "a", large) },
+			expectWarningsCount: 1,
+		},
+		{
+			name:     "only oversized header replaced",
+			fallback: "refetch",
+			set: func(r *http.Request) {
+				_ = Trigger(r, "a", "b")
+				_ = TriggerAfterSettle(r, "c", large)
+			},
+			expectTrigger:       `{"a":"b"}`,
+			expectAfterSettle:   "refetch",
+			expectWarningsCount: 1,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var warnings []error
+			mw := NewMiddleware(
+				WithMaxTriggerSize(50, c.fallback),
+				WithStrict(func(_ *http.Request, err error) { warnings = append(warnings, err) }),
+			)
+
+			rec := serve(mw, httptest.NewRequest(http.MethodGet, "/", nil), func(w http.ResponseWriter, r *http.Request) {
+				c.set(r)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			if actual := rec.Header().Get("HX-Trigger"); actual != c.expectTrigger {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expectTrigger)
+			}
+			if actual := rec.Header().Get("HX-Trigger-After-Settle"); actual != c.expectAfterSettle {
+				t.Errorf("HX-Trigger-After-Settle = %q, expected %q", actual, c.expectAfterSettle)
+			}
+
+			if len(warnings) != c.expectWarningsCount {
+				t.Fatalf("warnings = %v, expected %d", warnings, c.expectWarningsCount)
+			}
+			for _, err := range warnings {
+				if !errors.Is(err, ErrTriggerTooLarge) {
+					t.Errorf("warning = %v, expected it to wrap %v", err, ErrTriggerTooLarge)
+				}
+			}
+		})
+	}
+}
+
+func TestWithAudit(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		opts   []MiddlewareOption
+		set    func(r *http.Request)
+		expect []string
+	}{
+		{
+			name:   "no headers",
+			set:    func(*http.Request) {},
+			expect: []string{},
+		},
+		{
+			name: "headers",
+			set: func(r *http.Request) {
+				Redirect(r, "/a")
+				Retarget(r, "#main")
+				AddExtraHeader(r, "X-App-Version", "1.2.3")
+			},
+			expect: []string{"Hx-Redirect", "Hx-Retarget", "X-App-Version"},
+		},
+		{
+			name: "disabled",
+			opts: []MiddlewareOption{WithDisabled("HX-Redirect")},
+			set: func(r *http.Request) {
+				Redirect(r, "/a")
+				Retarget(r, "#main")
+			},
+			expect: []string{"Hx-Retarget"},
+		},
+		{
+			name: "cross-origin",
+			opts: []MiddlewareOption{WithSameOriginURLs(nil)},
+			set: func(r *http.Request) {
+				Redirect(r, "https://evil.com")
+				PushURL(r, "/a")
+			},
+			expect: []string{"Hx-Push-Url"},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				calls   int
+				emitted []string
+			)
+			opts := append([]MiddlewareOption{WithAudit(func(_ *http.Request, e []string) {
+				calls++
+				emitted = e
+			})}, c.opts...)
+
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			rec := serve(NewMiddleware(opts...), r, func(w http.ResponseWriter, r *http.Request) {
+				c.set(r)
+				_, _ = w.Write([]byte("abc"))
+			})
+
+			if calls != 1 {
+				t.Errorf("audit called %d times, expected once", calls)
+			}
+			if !reflect.DeepEqual(emitted, c.expect) {
+				t.Errorf("emitted = %q, expected %q", emitted, c.expect)
+			}
+
+			// emitted must match the wire output
+			for name := range rec.Header() {
+				if (strings.HasPrefix(name, "Hx-") || strings.HasPrefix(name, "X-")) && !slices.Contains(emitted, name) {
+					t.Errorf("%s was written, but not reported", name)
+				}
+			}
+			for _, name := range emitted {
+				if rec.Header().Get(name) == "" {
+					t.Errorf("%s was reported, but not written", name)
+				}
+			}
+		})
+	}
+}
+
+func TestWithBypass(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		path   string
+		bypass bool
+	}{
+		{name: "bypassed", path: "/healthz", bypass: true},
+		{name: "handled", path: "/items", bypass: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			mw := NewMiddleware(
+				WithBypass(func(r *http.Request) bool { return r.URL.Path == "/healthz" }),
+				WithVaryHeaders(),
+				WithDeprecation("deprecated", "use /v2"),
+			)
+
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.Header.Set("HX-Request", "true")
+
+			rec := httptest.NewRecorder()
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if wrapped := w != http.ResponseWriter(rec); wrapped == c.bypass {
+					t.Errorf("response writer wrapped = %t, expected %t", wrapped, !c.bypass)
+				}
+				if isHTMX := IsHTMX(r); isHTMX == c.bypass {
+					t.Errorf("IsHTMX() = %t, expected %t", isHTMX, !c.bypass)
+				}
+				if _, ok := ResponseOK(r); ok == c.bypass {
+					t.Errorf("ResponseOK() = %t, expected %t", ok, !c.bypass)
+				}
+
+				Retarget(r, "#main")
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(rec, r)
+
+			for _, name := range []string{"HX-Retarget", "HX-Trigger", "Vary"} {
+				if sent := rec.Header().Get(name) != ""; sent == c.bypass {
+					t.Errorf("%s sent = %t, expected %t", name, sent, !c.bypass)
+				}
+			}
+		})
+	}
+}
+
+func TestWithHTMXOnly(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		htmx           bool
+		expectOK       bool
+		expectRetarget string
+	}{
+		{name: "htmx request", htmx: true, expectOK: true, expectRetarget: "#main"},
+		{name: "non-htmx request", htmx: false, expectOK: false, expectRetarget: ""},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.htmx {
+				r.Header.Set("HX-Request", "true")
+			}
+
+			mw := NewMiddleware(WithHTMXOnly(), WithVaryHeaders())
+			rec := serve(mw, r, func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := ResponseOK(r); ok != c.expectOK {
+					t.Errorf("ResponseOK() reports %t, expected %t", ok, c.expectOK)
+				}
+				if _, wrapped := w.(*responseWriterWrapper); wrapped != c.expectOK {
+					t.Errorf("response writer wrapped: %t, expected %t", wrapped, c.expectOK)
+				}
+
+				Retarget(r, "#main")
+				_, _ = w.Write([]byte("abc"))
+			})
+
+			if actual := rec.Header().Get("HX-Retarget"); actual != c.expectRetarget {
+				t.Errorf("HX-Retarget = %q, expected %q", actual, c.expectRetarget)
+			}
+			if actual := rec.Header().Get("Vary"); actual != "HX-Request" {
+				t.Errorf("Vary = %q, expected %q", actual, "HX-Request")
+			}
+		})
+	}
+}
+
+// discardWriter is an [http.ResponseWriter] that discards everything written
+// to it, without allocating.
+type discardWriter struct{ h http.Header }
+
+func (w discardWriter) Header() http.Header       { return w.h }
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardWriter) WriteHeader(int)             {}
+
+func BenchmarkWithHTMXOnly(b *testing.B) {
+	h := NewMiddleware(WithHTMXOnly())(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		Retarget(r, "#main")
+		Reswap(r, SwapOuterHTML)
+		TriggerEvent(r, "a")
+	}))
+
+	var w http.ResponseWriter = discardWriter{h: make(http.Header)}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(w, r)
+	}
+}
+
+func TestWithVaryHeaders(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		names  []string
+		htmx   bool
+		expect string
+	}{
+		{name: "default", expect: "HX-Request"},
+		{name: "default htmx request", htmx: true, expect: "HX-Request"},
+		{name: "names", names: []string{"HX-Request", "HX-Target"}, expect: "HX-Request, HX-Target"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.htmx {
+				r.Header.Set("HX-Request", "true")
+			}
+
+			rec := serve(NewMiddleware(WithVaryHeaders(c.names...)), r, func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("abc"))
+			})
+
+			if actual := rec.Header().Values("Vary"); len(actual) != 1 || actual[0] != c.expect {
+				t.Errorf("Vary = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		existing []string
+		names    []string
+		expect   []string
+	}{
+		{name: "no existing", names: []string{"HX-Request"}, expect: []string{"HX-Request"}},
+		{
+			name:     "append",
+			existing: []string{"Accept-Encoding"},
+			names:    []string{"HX-Request"},
+			expect:   []string{"Accept-Encoding, HX-Request"},
+		},
+		{
+			name:     "multiple existing values",
+			existing: []string{"Accept-Encoding", "Cookie, Origin"},
+			names:    []string{"HX-Request", "HX-Target"},
+			expect:   []string{"Accept-Encoding, Cookie, Origin, HX-Request, HX-Target"},
+		},
+		{
+			name:     "dedupe existing",
+			existing: []string{"hx-request"},
+			names:    []string{"HX-Request"},
+			expect:   []string{"hx-request"},
+		},
+		{
+			name:   "dedupe names",
+			names:  []string{"HX-Request", "HX-Target", "hx-request"},
+			expect: []string{"HX-Request, HX-Target"},
+		},
+		{
+			name:     "wildcard",
+			existing: []string{"*"},
+			names:    []string{"HX-Request"},
+			expect:   []string{"*"},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := make(http.Header)
+			for _, val := range c.existing {
+				h.Add("Vary", val)
+			}
+
+			addVary(h, c.names)
+
+			if actual := h.Values("Vary"); !reflect.DeepEqual(actual, c.expect) {
+				t.Errorf("Vary = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		set  func(r *http.Request)
+	}{
+		{name: "Location", set: func(r *http.Request) { _ = Location(r, NewLocation("/a").WithTarget("#main")) }},
+		{name: "LocationPath", set: func(r *http.Request) { LocationPath(r, "/a") }},
+		{name: "ClearLocation", set: ClearLocation},
+		{name: "ClearResponse", set: ClearResponse},
+		{name: "PushURL", set: func(r *http.Request) { PushURL(r, "/a") }},
+		{name: "PushURLIfChanged", set: func(r *http.Request) { PushURLIfChanged(r, "/a") }},
+		{name: "PreventPushURL", set: PreventPushURL},
+		{name: "Redirect", set: func(r *http.Request) { Redirect(r, "/a") }},
+		{name: "RedirectOnly", set: func(r *http.Request) { RedirectOnly(r, "/a") }},
+		{name: "ExternalRedirect", set: func(r *http.Request) { ExternalRedirect(r, "https://example.org") }},
+		{name: "ClearRedirect", set: ClearRedirect},
+		{name: "Refresh", set: func(r *http.Request) { Refresh(r, true) }},
+		{name: "ReplaceURL", set: func(r *http.Request) { ReplaceURL(r, "/a") }},
+		{name: "PreventReplaceURL", set: PreventReplaceURL},
+		{name: "History", set: func(r *http.Request) { _ = History(r, PushHistory("/a")) }},
+		{name: "Reswap", set: func(r *http.Request) { Reswap(r, SwapOuterHTML) }},
+		{name: "Retarget", set: func(r *http.Request) { Retarget(r, "#main") }},
+		{name: "RetargetClosest", set: func(r *http.Request) { _ = RetargetClosest(r, "tr") }},
+		{name: "Reselect", set: func(r *http.Request) { Reselect(r, "#main") }},
+		{name: "SetStatus", set: func(r *http.Request) { SetStatus(r, StatusStopPolling) }},
+		{name: "AddExtraHeader", set: func(r *http.Request) { AddExtraHeader(r, "X-A", "b") }},
+		{name: "Trigger", set: func(r *http.Request) { _ = Trigger(r, "a", 1) }},
+		{name: "TriggerEvent", set: func(r *http.Request) { TriggerEvent(r, "a") }},
+		{name: "TriggerAppend", set: func(r *http.Request) { _ = TriggerAppend(r, "a", 1) }},
+		{name: "TriggerAfterSettle", set: func(r *http.Request) { _ = TriggerAfterSettle(r, "a", 1) }},
+		{name: "TriggerAfterSwap", set: func(r *http.Request) { _ = TriggerAfterSwap(r, "a", 1) }},
+		{name: "TriggerE", set: func(r *http.Request) { TriggerE(r, "a", 1) }},
+		{name: "TriggerMerge", set: func(r *http.Request) { _ = TriggerMerge(r, "a", map[string]any{"b": 1}) }},
+		{name: "TriggerDelayed", set: func(r *http.Request) { _ = TriggerDelayed(r, "a", time.Second, nil) }},
+		{name: "Untrigger", set: func(r *http.Request) { Untrigger(r, "a") }},
+		{name: "SetTitle", set: func(r *http.Request) { SetTitle(r, "a") }},
+		{name: "SetPollInterval", set: func(r *http.Request) { SetPollInterval(r, time.Second) }},
+		{name: "StopPolling", set: StopPolling},
+		{name: "OOBSwap", set: func(r *http.Request) { OOBSwap(r, "#a", SwapOuterHTML) }},
+		{name: "Apply", set: func(r *http.Request) { _ = Apply(r, Directive{Retarget: "#main", Refresh: true}) }},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(NewMiddleware(WithHTMXOnly()), r, func(w http.ResponseWriter, r *http.Request) {
+				c.set(r)
+				_, _ = w.Write([]byte("abc"))
+			})
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, expected %d", rec.Code, http.StatusOK)
+			}
+			for name := range rec.Header() {
+				if strings.HasPrefix(name, "Hx-") || name == "X-A" {
+					t.Errorf("expected %s not to be sent to a non-htmx client", name)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseOK(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		r        *http.Request
+		expectOK bool
+	}{
+		{name: "middleware", r: newTestRequest(), expectOK: true},
+		{name: "no middleware", r: httptest.NewRequest(http.MethodGet, "/", nil), expectOK: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			h, ok := ResponseOK(c.r)
+			if ok != c.expectOK {
+				t.Errorf("ResponseOK() reports %t, expected %t", ok, c.expectOK)
+			}
+			if h == nil {
+				t.Fatal("ResponseOK() returned nil response headers")
+			}
+
+			// setters must not panic on detached headers
+			Retarget(c.r, "#main")
+			_ = Trigger(c.r, "a", 1)
+
+			if actual, _ := ResponseOK(c.r); (actual == h) != c.expectOK {
+				t.Errorf("ResponseOK() returned the same headers: %t, expected %t", actual == h, c.expectOK)
+			}
+			if actual := Response(c.r).Retarget; (actual == "#main") != c.expectOK {
+				t.Errorf("Retarget = %q, expected it to be kept: %t", actual, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestForwardTo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		next http.HandlerFunc
+	}{
+		{
+			name: "derived context",
+			next: func(_ http.ResponseWriter, r *http.Request) {
+				_ = Trigger(r, "inner", nil)
+			},
+		},
+		{
+			name: "replaced context",
+			next: func(_ http.ResponseWriter, r *http.Request) {
+				_ = Trigger(r, "inner", nil)
+				*r = *r.WithContext(context.Background())
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+				ForwardTo(w, r, c.next)
+				_ = Trigger(r, "outer", nil)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			actual := rec.Header().Get("HX-Trigger")
+			if actual != "inner,outer" && actual != "outer,inner" {
+				t.Errorf("HX-Trigger = %q, expected it to contain inner and outer", actual)
+			}
+		})
+	}
+}
+
+// frameworkContext mimics the context of frameworks such as Echo, which hold
+// on to the request and wrap the response writer.
+type frameworkContext struct {
+	req *http.Request
+	res *frameworkResponse
+}
+
+type frameworkResponse struct {
+	http.ResponseWriter
+	committed bool
+}
+
+func (r *frameworkResponse) WriteHeader(code int) {
+	r.committed = true
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *frameworkResponse) Write(p []byte) (int, error) {
+	if !r.committed {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+func TestNewMiddleware_framework(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// adopt determines whether the framework adopts the request passed
+		// to the wrapped handler, as echo.WrapMiddleware does.
+		adopt bool
+	}{
+		{name: "adopted request", adopt: true},
+		{name: "original request", adopt: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := func(fc *frameworkContext) {
+				Retarget(fc.req, "#main")
+				fc.res.Header().Set("Content-Type", "text/html; charset=utf-8")
+				_, _ = fc.res.Write([]byte("<p>Hello</p>"))
+			}
+
+			rec := httptest.NewRecorder()
+			fc := &frameworkContext{
+				req: httptest.NewRequest(http.MethodGet, "/", nil),
+				res: &frameworkResponse{ResponseWriter: rec},
+			}
+
+			NewMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if c.adopt {
+					fc.req = r
+				}
+				fc.res = &frameworkResponse{ResponseWriter: w}
+				handler(fc)
+			})).ServeHTTP(fc.res, fc.req)
+
+			if actual := rec.Header().Get("HX-Retarget"); actual != "#main" {
+				t.Errorf("HX-Retarget = %q, expected %q", actual, "#main")
+			}
+			if actual := rec.Body.String(); actual != "<p>Hello</p>" {
+				t.Errorf("body = %q, expected %q", actual, "<p>Hello</p>")
+			}
+		})
+	}
+}
+
+func TestMiddleware_routeGroup(t *testing.T) {
+	t.Parallel()
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		Retarget(r, "#main")
+		_, ok := ResponseOK(r)
+		_, _ = w.Write([]byte(strconv.FormatBool(ok)))
+	}
+
+	// only routes in the /app/ group use the middleware
+	var mw Middleware = NewMiddleware()
+
+	group := http.NewServeMux()
+	group.HandleFunc("/app/a", handler)
+
+	mux := http.NewServeMux()
+	mux.Handle("/app/", mw(group))
+	mux.HandleFunc("/public", handler)
+
+	testCases := []struct {
+		target         string
+		expectRetarget string
+		expectBody     string
+	}{
+		{target: "/app/a", expectRetarget: "#main", expectBody: "true"},
+		{target: "/public", expectRetarget: "", expectBody: "false"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.target, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.target, nil))
+
+			if actual := rec.Header().Get("HX-Retarget"); actual != c.expectRetarget {
+				t.Errorf("HX-Retarget = %q, expected %q", actual, c.expectRetarget)
+			}
+			if actual := rec.Body.String(); actual != c.expectBody {
+				t.Errorf("body = %q, expected %q", actual, c.expectBody)
+			}
+		})
+	}
+}
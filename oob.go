@@ -0,0 +1,113 @@
+package htmx
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// OOBSet is a set of out-of-band swaps, that are rendered after the main
+// content of a response.
+//
+// The zero value is an empty set ready to use.
+//
+// See: https://htmx.org/attributes/hx-swap-oob
+type OOBSet struct {
+	swaps []oobSwap
+}
+
+type oobSwap struct {
+	target   Selector
+	strategy SwapStrategy
+	content  template.HTML
+}
+
+// Add adds an out-of-band swap of content into the elements matching target,
+// using the passed strategy.
+//
+// The content is wrapped in a div carrying the hx-swap-oob attribute.
+// For all strategies but [SwapOuterHTML], htmx swaps in the children of that
+// div.
+// For SwapOuterHTML, the div itself replaces the target.
+func (s *OOBSet) Add(target Selector, strategy SwapStrategy, content template.HTML) {
+	s.swaps = append(s.swaps, oobSwap{target: target, strategy: strategy, content: content})
+}
+
+// AddIf is the same as [OOBSet.Add], but only adds the swap, if cond is
+// true.
+func (s *OOBSet) AddIf(cond bool, target Selector, strategy SwapStrategy, content template.HTML) {
+	if cond {
+		s.Add(target, strategy, content)
+	}
+}
+
+// AddForTarget is the same as [OOBSet.Add], but only adds the swap, if r is
+// an htmx request targeting the element with the passed id.
+//
+// This is useful for endpoints serving multiple targets, for which some
+// out-of-band swaps are only relevant for some of the targets.
+func (s *OOBSet) AddForTarget(
+	r *http.Request, reqTarget ID, target Selector, strategy SwapStrategy, content template.HTML,
+) {
+	req := Request(r)
+	s.AddIf(req != nil && req.Target == reqTarget, target, strategy, content)
+}
+
+// HTML renders the out-of-band swaps in the order they were added.
+//
+// If s is nil, HTML returns an empty string.
+func (s *OOBSet) HTML() template.HTML {
+	if s == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, swap := range s.swaps {
+		b.WriteString("<div ")
+		b.WriteString(string(OOBIntent{Selector: swap.target, Strategy: swap.strategy}.Attr()))
+		b.WriteString(">")
+		b.WriteString(string(swap.content))
+		b.WriteString("</div>")
+	}
+
+	//nolint:gosec // attributes are escaped, content is already HTML
+	return template.HTML(b.String())
+}
+
+// OOBIntent is an intended out-of-band swap, recorded using [OOBSwap].
+type OOBIntent struct {
+	// Selector is the selector of the elements to swap.
+	Selector Selector
+	// Strategy is the strategy used to swap the elements.
+	Strategy SwapStrategy
+}
+
+// AttrValue returns the value of the hx-swap-oob attribute implementing the
+// swap, e.g. "innerHTML:#cart".
+func (i OOBIntent) AttrValue() string {
+	return string(i.Strategy) + ":" + i.Selector
+}
+
+// Attr renders the hx-swap-oob attribute implementing the swap, e.g.
+// hx-swap-oob="innerHTML:#cart".
+func (i OOBIntent) Attr() template.HTMLAttr {
+	//nolint:gosec // escaped
+	return template.HTMLAttr(`hx-swap-oob="` + html.EscapeString(i.AttrValue()) + `"`)
+}
+
+// OOBSwap records the intent to swap the elements matching the passed
+// selector out-of-band, using the passed strategy.
+//
+// The intents are accessible through [ResponseHeaders.OOB], which allows
+// the rendering layer to annotate the rendered fragments with the
+// appropriate hx-swap-oob attributes, e.g. using [OOBIntent.Attr].
+//
+// Intents are appended to those recorded previously.
+func OOBSwap(r *http.Request, sel Selector, strategy SwapStrategy) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.OOB = append(resp.OOB, OOBIntent{Selector: sel, Strategy: strategy})
+}
@@ -0,0 +1,195 @@
+package htmx
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOOBSet_HTML(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    *OOBSet
+		expect template.HTML
+	}{
+		{name: "nil", set: nil, expect: ""},
+		{name: "empty", set: new(OOBSet), expect: ""},
+		{
+			name: "single",
+			set: func() *OOBSet {
+				var s OOBSet
+				s.Add("#cart", SwapInnerHTML, "<span>3</span>")
+				return &s
+			}(),
+			expect: `<div hx-swap-oob="innerHTML:#cart"><span>3</span></div>`,
+		},
+		{
+			name: "multiple",
+			set: func() *OOBSet {
+				var s OOBSet
+				s.Add("#a", SwapOuterHTML, "a")
+				s.Add(`[name="b"]`, SwapBeforeEnd, "b")
+				return &s
+			}(),
+			expect: `<div hx-swap-oob="outerHTML:#a">a</div>` +
+				`<div hx-swap-oob="beforeend:[name=&#34;b&#34;]">b</div>`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.set.HTML(); actual != c.expect {
+				t.Errorf("HTML() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestOOBSet_AddIf(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		cond   bool
+		expect template.HTML
+	}{
+		{name: "true", cond: true, expect: `<div hx-swap-oob="innerHTML:#count">3</div>`},
+		{name: "false", cond: false, expect: ""},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var s OOBSet
+			s.AddIf(c.cond, "#count", SwapInnerHTML, "3")
+
+			if actual := s.HTML(); actual != c.expect {
+				t.Errorf("HTML() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestOOBSet_AddForTarget(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect template.HTML
+	}{
+		{
+			name:   "matching target",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}},
+			expect: `<div hx-swap-oob="innerHTML:#sidebar-count">3</div>`,
+		},
+		{name: "other target", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"detail"}}},
+		{name: "no target", header: http.Header{"Hx-Request": {"true"}}},
+		{name: "non-htmx request", header: http.Header{"Hx-Target": {"list"}}},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			var s OOBSet
+			s.AddForTarget(r, "list", "#sidebar-count", SwapInnerHTML, "3")
+
+			if actual := s.HTML(); actual != c.expect {
+				t.Errorf("HTML() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestOOBIntent_AttrValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		i      OOBIntent
+		expect string
+	}{
+		{name: "id", i: OOBIntent{Selector: "#cart", Strategy: SwapInnerHTML}, expect: "innerHTML:#cart"},
+		{name: "class", i: OOBIntent{Selector: ".count", Strategy: SwapOuterHTML}, expect: "outerHTML:.count"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.i.AttrValue(); actual != c.expect {
+				t.Errorf("AttrValue() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestOOBIntent_Attr(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		i      OOBIntent
+		expect template.HTMLAttr
+	}{
+		{
+			name:   "id",
+			i:      OOBIntent{Selector: "#cart", Strategy: SwapInnerHTML},
+			expect: `hx-swap-oob="innerHTML:#cart"`,
+		},
+		{
+			name:   "escaped",
+			i:      OOBIntent{Selector: `[name="a"]`, Strategy: SwapOuterHTML},
+			expect: `hx-swap-oob="outerHTML:[name=&#34;a&#34;]"`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.i.Attr(); actual != c.expect {
+				t.Errorf("Attr() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestOOBSwap(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRequest()
+	OOBSwap(r, "#cart", SwapInnerHTML)
+	OOBSwap(r, "#flash", SwapOuterHTML)
+
+	expect := []OOBIntent{
+		{Selector: "#cart", Strategy: SwapInnerHTML},
+		{Selector: "#flash", Strategy: SwapOuterHTML},
+	}
+	if actual := Response(r).OOB; !reflect.DeepEqual(actual, expect) {
+		t.Errorf("OOB = %v, expected %v", actual, expect)
+	}
+
+	if h := headers(r); len(h) > 0 {
+		t.Errorf("expected OOB swaps not to be sent as headers, got %v", h)
+	}
+	if !ResponseModified(r) {
+		t.Error("expected the response to be modified")
+	}
+}
@@ -0,0 +1,236 @@
+package htmx
+
+import (
+	"encoding/json"
+	"html"
+	"html/template"
+	"net/http"
+)
+
+// LoadMoreEvent is the event triggered by [LoadMore].
+var LoadMoreEvent Event = "loadMore"
+
+// LoadMore configures the response to a "load more" request, by appending
+// the response to the list matching itemsTarget, and telling the client the
+// URL of the next page.
+//
+// It retargets the response to itemsTarget, swaps it using [SwapBeforeEnd],
+// and triggers [LoadMoreEvent] with the next URL in the event's detail, e.g.
+// {"next": "/items?page=3"}.
+// If there are no more pages, nextURL should be empty.
+//
+// The expected markup is a list followed by a button loading the next page:
+//
+//	<ul id="items">...</ul>
+//	<button id="load-more" hx-get="/items?page=2">Load more</button>
+//
+// Since the button is not swapped, a client-side listener must update its
+// URL, or remove it, if there are no more pages:
+//
+//	document.body.addEventListener("loadMore", (evt) => {
+//	    const btn = document.getElementById("load-more");
+//	    if (!evt.detail.next) {
+//	        btn.remove();
+//	        return;
+//	    }
+//	    btn.setAttribute("hx-get", evt.detail.next);
+//	    htmx.process(btn);
+//	});
+//
+// Previous values are overwritten.
+func LoadMore(r *http.Request, itemsTarget Selector, nextURL URL) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.Retarget = itemsTarget
+	resp.Reswap = SwapBeforeEnd
+	resp.Trigger[LoadMoreEvent], _ = json.Marshal(map[string]string{"next": nextURL}) // strings never fail
+}
+
+// FieldErrorID returns the id of the element displaying the error of the
+// field with the passed id.
+//
+// By default, it appends "-error" to the field's id, e.g. "email-error" for
+// the field "email".
+// Change it, if your application uses a different convention.
+var FieldErrorID = func(field ID) ID {
+	return field + "-error"
+}
+
+// FieldError displays the passed message in the error element of the field
+// with the passed id, as determined by [FieldErrorID].
+//
+// It retargets the response to the error element, swaps its inner HTML, and
+// returns the HTML-escaped message, which must be written as the body of the
+// response:
+//
+//	<input id="email" name="email" hx-post="/validate/email">
+//	<span id="email-error"></span>
+//
+//	io.WriteString(w, string(htmx.FieldError(r, "email", "Invalid email.")))
+//
+// Previous values are overwritten.
+func FieldError(r *http.Request, field ID, message string) template.HTML {
+	if resp := attachedResponse(r); resp != nil {
+		resp.Retarget = IDSelector(FieldErrorID(field))
+		resp.Reswap = SwapInnerHTML
+	}
+
+	return template.HTML(html.EscapeString(message)) //nolint:gosec // escaped
+}
+
+// PushOnly pushes u into the browser's history, without swapping any
+// content, by setting HX-Push-Url to u and HX-Reswap to [SwapNone].
+//
+// This is useful, if only the address bar needs to change, e.g. when
+// switching tabs that are handled client-side.
+// Setting HX-Reswap prevents the, presumably empty, response from being
+// swapped into the target.
+//
+// An error is returned, if u is not of the same origin as r.
+// In that case, the headers remain unchanged.
+//
+// Previous values are overwritten.
+func PushOnly(r *http.Request, u SameOriginURL) error {
+	if err := PushURLChecked(r, u); err != nil {
+		return err
+	}
+
+	Reswap(r, SwapNone)
+	return nil
+}
+
+// Navigate sets the metadata of a navigation-like fragment response, by
+// pushing u into the browser's history, and setting the title of the
+// document to title using [SetTitle].
+//
+// Updating the title requires the client-side listener described by
+// SetTitle.
+//
+// An error is returned, if u is not of the same origin as r.
+// In that case, the headers remain unchanged.
+//
+// Previous values are overwritten.
+func Navigate(r *http.Request, u SameOriginURL, title string) error {
+	if err := PushURLChecked(r, u); err != nil {
+		return err
+	}
+
+	SetTitle(r, title)
+	return nil
+}
+
+// ReplaceOnly replaces the current URL in the browser's history with u,
+// without swapping any content, by setting HX-Replace-Url to u and
+// HX-Reswap to [SwapNone].
+//
+// This is useful e.g. for filters, whose results are updated out-of-band,
+// but whose state should be reflected in a shareable URL.
+//
+// Use ReplaceOnly, if the change is minor and the back button should skip
+// it, and [PushOnly], if the user should be able to navigate back to the
+// previous state.
+//
+// An error is returned, if u is not of the same origin as r.
+// In that case, the headers remain unchanged.
+//
+// Previous values are overwritten.
+func ReplaceOnly(r *http.Request, u SameOriginURL) error {
+	if err := ReplaceURLChecked(r, u); err != nil {
+		return err
+	}
+
+	Reswap(r, SwapNone)
+	return nil
+}
+
+// ReplaceBody replaces the whole body of the document with the response, by
+// setting HX-Retarget to "body" and HX-Reswap to [SwapOuterHTML].
+//
+// This is a heavy-handed operation:
+// All client-side state of the page is lost, and attributes set on the body,
+// such as hx-boost or hx-ext, must be included in the response.
+// Also note that the URL doesn't change, so the browser's history will
+// not reflect the new content, unless combined with [PushURL].
+//
+// Previous values are overwritten.
+func ReplaceBody(r *http.Request) {
+	resp := attachedResponse(r)
+	if resp == nil {
+		return
+	}
+	resp.Retarget = "body"
+	resp.Reswap = SwapOuterHTML
+}
+
+// WizardStep navigates to the step of a multi-step wizard at stepURL, by
+// setting HX-Location to load stepURL into the wizard's container, using
+// [SwapInnerHTML].
+// As with all locations, stepURL is pushed into the browser's history.
+//
+// Since only the container is swapped, state kept outside of it is
+// preserved.
+// To pass state to the next step, use [Location] directly, and set
+// [LocationData.Values].
+//
+// An error is returned, if container is not a valid selector.
+//
+// Previous values are overwritten.
+func WizardStep(r *http.Request, stepURL URL, container Selector) error {
+	return Location(r, LocationData{Path: stepURL, Target: container, Swap: SwapInnerHTML})
+}
+
+// ClearStateEvent is the event triggered by [NoBackCache].
+var ClearStateEvent Event = "clearState"
+
+// NoBackCache prevents the response from being restored from the browser's
+// caches, e.g. after logging out.
+//
+// It sets Cache-Control to no-store, which prevents the browser from
+// caching the response and, in most browsers, excludes the page from the
+// back/forward cache (bfcache).
+//
+// However, htmx keeps its own history cache in localStorage, which is not
+// affected by Cache-Control.
+// NoBackCache therefore also triggers [ClearStateEvent], which requires a
+// client-side listener to clear that, and any other sensitive state:
+//
+//	document.body.addEventListener("clearState", () => {
+//	    localStorage.removeItem("htmx-history-cache");
+//	});
+func NoBackCache(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[ClearStateEvent] = nil
+	}
+}
+
+// VersionConflictEvent is the event triggered by [VersionConflict].
+var VersionConflictEvent Event = "versionConflict"
+
+// VersionConflict responds to a stale submit, whose version, as returned by
+// [RequestHeaders.Version], doesn't match the current version of the
+// resource.
+//
+// It triggers [VersionConflictEvent] with the current version in the event's
+// detail, e.g. {"version": "8"}, retargets the response to target, e.g. the
+// element displaying the conflict in the submitted form, and writes a 409
+// Conflict status.
+// If target is empty, the response is not retargeted.
+// The body, e.g. a fragment explaining the conflict, may be written
+// afterwards.
+//
+// Note that htmx doesn't swap 4xx responses by default.
+// To display the body, htmx must be configured to swap 409 responses, e.g.
+// using the htmx:beforeSwap event.
+func VersionConflict(w http.ResponseWriter, r *http.Request, current string, target Selector) {
+	if resp := attachedResponse(r); resp != nil {
+		resp.Trigger[VersionConflictEvent], _ = json.Marshal(map[string]string{"version": current}) // never fails
+		if target != "" {
+			resp.Retarget = target
+		}
+	}
+
+	w.WriteHeader(http.StatusConflict)
+}
@@ -0,0 +1,437 @@
+package htmx
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMore(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		nextURL URL
+		expect  http.Header
+	}{
+		{
+			name:    "next page",
+			nextURL: "/items?page=3",
+			expect: http.Header{
+				"Hx-Retarget": {"#items"},
+				"Hx-Reswap":   {"beforeend"},
+				"Hx-Trigger":  {`{"loadMore":{"next":"/items?page=3"}}`},
+			},
+		},
+		{
+			name:    "last page",
+			nextURL: "",
+			expect: http.Header{
+				"Hx-Retarget": {"#items"},
+				"Hx-Reswap":   {"beforeend"},
+				"Hx-Trigger":  {`{"loadMore":{"next":""}}`},
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			LoadMore(r, "#items", c.nextURL)
+
+			if actual := headers(r); !reflect.DeepEqual(actual, c.expect) {
+				t.Errorf("headers = %v, expected %v", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		field          ID
+		message        string
+		expectRetarget string
+		expectHTML     template.HTML
+	}{
+		{
+			name:           "message",
+			field:          "email",
+			message:        "Invalid email.",
+			expectRetarget: "#email-error",
+			expectHTML:     "Invalid email.",
+		},
+		{
+			name:           "escaped",
+			field:          "user.name",
+			message:        "<b>required</b>",
+			expectRetarget: `#user\.name-error`,
+			expectHTML:     "&lt;b&gt;required&lt;/b&gt;",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRequest()
+			if actual := FieldError(r, c.field, c.message); actual != c.expectHTML {
+				t.Errorf("FieldError() = %q, expected %q", actual, c.expectHTML)
+			}
+
+			h := headers(r)
+			if actual := h.Get("HX-Retarget"); actual != c.expectRetarget {
+				t.Errorf("HX-Retarget = %q, expected %q", actual, c.expectRetarget)
+			}
+			if actual := h.Get("HX-Reswap"); actual != "innerHTML" {
+				t.Errorf("HX-Reswap = %q, expected %q", actual, "innerHTML")
+			}
+		})
+	}
+}
+
+func TestPushOnly(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		u      SameOriginURL
+		expect http.Header
+	}{
+		{
+			name:   "relative",
+			u:      "/tabs/2",
+			expect: http.Header{"Hx-Push-Url": {"/tabs/2"}, "Hx-Reswap": {"none"}},
+		},
+		{
+			name:   "absolute same origin",
+			u:      "http://example.com/tabs/2",
+			expect: http.Header{"Hx-Push-Url": {"http://example.com/tabs/2"}, "Hx-Reswap": {"none"}},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := PushOnly(r, c.u); err != nil {
+					t.Fatalf("PushOnly returned error: %v", err)
+				}
+
+				if actual := headers(r); !reflect.DeepEqual(actual, c.expect) {
+					t.Errorf("headers = %v, expected %v", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name string
+		u    SameOriginURL
+	}{
+		{name: "cross-origin", u: "https://evil.com/tabs/2"},
+		{name: "scheme-relative", u: "//evil.com"},
+		{name: "backslash", u: `/\evil.com`},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := PushOnly(r, c.u); err == nil {
+					t.Fatal("expected PushOnly to return an error")
+				}
+
+				if h := headers(r); len(h) > 0 {
+					t.Errorf("expected no headers to be set, got %v", h)
+				}
+			})
+		}
+	})
+}
+
+func TestReplaceBody(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRequest()
+	Retarget(r, "#main")
+	Reswap(r, SwapInnerHTML)
+	ReplaceBody(r)
+
+	expect := http.Header{"Hx-Retarget": {"body"}, "Hx-Reswap": {"outerHTML"}}
+	if actual := headers(r); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("headers = %v, expected %v", actual, expect)
+	}
+}
+
+func TestWizardStep(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name      string
+		stepURL   URL
+		container Selector
+		expect    string
+	}{
+		{
+			name:      "step",
+			stepURL:   "/signup/2",
+			container: "#wizard",
+			expect:    `{"path":"/signup/2","target":"#wizard","swap":"innerHTML"}`,
+		},
+		{
+			name:      "step with query",
+			stepURL:   "/signup?step=3",
+			container: "#wizard",
+			expect:    `{"path":"/signup?step=3","target":"#wizard","swap":"innerHTML"}`,
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := WizardStep(r, c.stepURL, c.container); err != nil {
+					t.Fatalf("WizardStep returned error: %v", err)
+				}
+
+				if actual := headers(r).Get("HX-Location"); actual != c.expect {
+					t.Errorf("HX-Location = %q, expected %q", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name      string
+		stepURL   URL
+		container Selector
+	}{
+		{name: "missing step url", container: "#wizard"},
+		{name: "invalid container", stepURL: "/signup/2", container: "#wizard["},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := WizardStep(r, c.stepURL, c.container); err == nil {
+					t.Fatal("expected WizardStep to return an error")
+				}
+
+				if h := headers(r); len(h) > 0 {
+					t.Errorf("expected no headers to be set, got %v", h)
+				}
+			})
+		}
+	})
+}
+
+func TestNoBackCache(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+		NoBackCache(w, r)
+		_, _ = w.Write([]byte("abc"))
+	})
+
+	if actual := rec.Header().Get("Cache-Control"); actual != "no-store" {
+		t.Errorf("Cache-Control = %q, expected %q", actual, "no-store")
+	}
+	if actual := rec.Header().Get("HX-Trigger"); actual != "clearState" {
+		t.Errorf("HX-Trigger = %q, expected %q", actual, "clearState")
+	}
+}
+
+func TestVersionConflict(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		version        string
+		target         Selector
+		expectStatus   int
+		expectTrigger  string
+		expectRetarget string
+	}{
+		{name: "matching version", version: "8", target: "#conflict", expectStatus: http.StatusOK},
+		{
+			name:           "conflicting version",
+			version:        "7",
+			target:         "#conflict",
+			expectStatus:   http.StatusConflict,
+			expectTrigger:  `{"versionConflict":{"version":"8"}}`,
+			expectRetarget: "#conflict",
+		},
+		{
+			name:          "conflicting version without target",
+			version:       "7",
+			expectStatus:  http.StatusConflict,
+			expectTrigger: `{"versionConflict":{"version":"8"}}`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPut, "/items/5", nil)
+			r.Header.Set("HX-Request", "true")
+			r.Header.Set("HX-Version", c.version)
+
+			rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+				const current = "8"
+				if v, _ := Request(r).Version("HX-Version"); v != current {
+					VersionConflict(w, r, current, c.target)
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			})
+
+			if rec.Code != c.expectStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, c.expectStatus)
+			}
+			if actual := rec.Header().Get("HX-Trigger"); actual != c.expectTrigger {
+				t.Errorf("HX-Trigger = %q, expected %q", actual, c.expectTrigger)
+			}
+			if actual := rec.Header().Get("HX-Retarget"); actual != c.expectRetarget {
+				t.Errorf("HX-Retarget = %q, expected %q", actual, c.expectRetarget)
+			}
+		})
+	}
+}
+
+func TestReplaceOnly(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		u      SameOriginURL
+		expect http.Header
+	}{
+		{
+			name:   "relative",
+			u:      "/items?status=open",
+			expect: http.Header{"Hx-Replace-Url": {"/items?status=open"}, "Hx-Reswap": {"none"}},
+		},
+		{
+			name:   "absolute same origin",
+			u:      "http://example.com/items",
+			expect: http.Header{"Hx-Replace-Url": {"http://example.com/items"}, "Hx-Reswap": {"none"}},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := ReplaceOnly(r, c.u); err != nil {
+					t.Fatalf("ReplaceOnly returned error: %v", err)
+				}
+
+				if actual := headers(r); !reflect.DeepEqual(actual, c.expect) {
+					t.Errorf("headers = %v, expected %v", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name string
+		u    SameOriginURL
+	}{
+		{name: "cross-origin", u: "https://evil.com/items"},
+		{name: "scheme-relative", u: "//evil.com"},
+		{name: "backslash", u: `/\evil.com`},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := ReplaceOnly(r, c.u); err == nil {
+					t.Fatal("expected ReplaceOnly to return an error")
+				}
+
+				if h := headers(r); len(h) > 0 {
+					t.Errorf("expected no headers to be set, got %v", h)
+				}
+			})
+		}
+	})
+}
+
+func TestNavigate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		if err := Navigate(r, "/inbox", "Inbox (3)"); err != nil {
+			t.Fatalf("Navigate returned error: %v", err)
+		}
+
+		expect := http.Header{
+			"Hx-Push-Url": {"/inbox"},
+			"Hx-Trigger":  {`{"setTitle":{"title":"Inbox (3)"}}`},
+		}
+		if actual := headers(r); !reflect.DeepEqual(actual, expect) {
+			t.Errorf("headers = %v, expected %v", actual, expect)
+		}
+	})
+
+	t.Run("cross-origin", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		if err := Navigate(r, "https://evil.com/inbox", "Inbox (3)"); err == nil {
+			t.Fatal("expected Navigate to return an error")
+		}
+
+		if h := headers(r); len(h) > 0 {
+			t.Errorf("expected no headers to be set, got %v", h)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package htmx
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+)
+
+// HXHeadersAttr renders an hx-headers attribute containing the passed
+// headers, e.g. hx-headers='{"X-CSRF-Token":"abc"}'.
+//
+// This can be used to tell the client to send additional headers, such as a
+// CSRF token or a tenant id, with the requests made by the element (and its
+// children).
+//
+// The JSON is escaped for use inside an HTML attribute.
+//
+// An error will only be returned if h can't be marshalled to json.
+func HXHeadersAttr(h Headers) (template.HTMLAttr, error) {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return "", fmt.Errorf("hx-headers: %w", err)
+	}
+
+	//nolint:gosec // escaped
+	return template.HTMLAttr("hx-headers='" + html.EscapeString(string(data)) + "'"), nil
+}
+
+// ViewTransitionNameAttr renders a style attribute assigning the passed
+// view-transition-name to an element, e.g.
+// style="view-transition-name: item-5".
+//
+// Together with a swap using [SwapStrategy.WithTransition], this allows
+// animating the element using a named view transition.
+//
+// name is escaped as a CSS identifier, so that it can't inject other
+// declarations, and then for use inside an HTML attribute.
+//
+// See: https://developer.mozilla.org/docs/Web/CSS/view-transition-name
+func ViewTransitionNameAttr(name string) template.HTMLAttr {
+	//nolint:gosec // escaped
+	return template.HTMLAttr(`style="view-transition-name: ` + html.EscapeString(cssEscape(name)) + `"`)
+}
+
+// NeedsFullRender reports whether the response to r must be a full HTML
+// document, instead of just a fragment.
+//
+// This is the case for all non-htmx requests, and for htmx requests
+// restoring history after a miss in the local history cache.
+func NeedsFullRender(r *http.Request) bool {
+	req := Request(r)
+	return req == nil || req.HistoryRestoreRequest
+}
+
+// NeedsHead reports whether the response to r should include the <head> of
+// the document.
+//
+// The rules are as follows:
+//
+//  1. Non-htmx requests need the head, as they render the full page.
+//  2. History restore requests need the head, as htmx swaps the full
+//     response into the body, and updates the title from it.
+//  3. Boosted requests need the head, if they don't target an element other
+//     than the body, i.e. if HX-Target is absent or "body".
+//     These are navigations, for which htmx merges the title of the response.
+//  4. All other requests, i.e. regular and boosted requests targeting a
+//     specific element, don't need the head.
+func NeedsHead(r *http.Request) bool {
+	req := Request(r)
+	switch {
+	case req == nil:
+		return true
+	case req.HistoryRestoreRequest:
+		return true
+	case req.Boosted:
+		return req.Target == "" || req.Target == "body"
+	default:
+		return false
+	}
+}
+
+// DocumentLayout is the layout used by [WrapDocument] to wrap a fragment in a
+// full HTML document.
+//
+// By default, it renders a minimal document with the passed title and the
+// fragment as body:
+//
+//	<!DOCTYPE html>
+//	<html>
+//	<head><meta charset="utf-8"><title>{{ title }}</title></head>
+//	<body>{{ fragment }}</body>
+//	</html>
+//
+// Set it to your own layout to include stylesheets, scripts, and the like.
+// Implementations must escape title themselves.
+var DocumentLayout = func(title string, fragment template.HTML) template.HTML {
+	//nolint:gosec // escaped
+	return template.HTML("<!DOCTYPE html>\n<html>\n" +
+		`<head><meta charset="utf-8"><title>` + html.EscapeString(title) + "</title></head>\n" +
+		"<body>" + string(fragment) + "</body>\n</html>\n")
+}
+
+// WrapDocument wraps the passed fragment in a full HTML document using
+// [DocumentLayout], if [NeedsFullRender] reports true for r.
+// Otherwise, it returns the fragment unchanged.
+//
+// This allows handlers that normally only render fragments to correctly
+// respond to history restore requests, which e.g. happen when using the
+// browser's back button, and to regular, non-htmx requests.
+func WrapDocument(r *http.Request, title string, fragment template.HTML) template.HTML {
+	if !NeedsFullRender(r) {
+		return fragment
+	}
+
+	return DocumentLayout(title, fragment)
+}
@@ -0,0 +1,193 @@
+package htmx
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHXHeadersAttr(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		h      Headers
+		expect template.HTMLAttr
+	}{
+		{name: "empty", h: Headers{}, expect: "hx-headers='{}'"},
+		{name: "single", h: Headers{"X-CSRF-Token": "abc"}, expect: `hx-headers='{&#34;X-CSRF-Token&#34;:&#34;abc&#34;}'`},
+		{
+			name:   "escaped",
+			h:      Headers{"X-A": `'"<>&`},
+			expect: `hx-headers='{&#34;X-A&#34;:&#34;&#39;\&#34;\u003c\u003e\u0026&#34;}'`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := HXHeadersAttr(c.h)
+			if err != nil {
+				t.Fatalf("HXHeadersAttr returned error: %v", err)
+			}
+
+			if actual != c.expect {
+				t.Errorf("HXHeadersAttr() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestViewTransitionNameAttr(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		in     string
+		expect template.HTMLAttr
+	}{
+		{name: "identifier", in: "item-5", expect: `style="view-transition-name: item-5"`},
+		{
+			name:   "escaped",
+			in:     `a"><script>`,
+			expect: `style="view-transition-name: a\&#34;\&gt;\&lt;script\&gt;"`,
+		},
+		{
+			name:   "css injection",
+			in:     "x; background:url(//evil)",
+			expect: `style="view-transition-name: x\;\ background\:url\(\/\/evil\)"`,
+		},
+		{name: "leading digit", in: "5", expect: `style="view-transition-name: \35 "`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := ViewTransitionNameAttr(c.in); actual != c.expect {
+				t.Errorf("ViewTransitionNameAttr(%q) = %q, expected %q", c.in, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestNeedsFullRender(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect bool
+	}{
+		{name: "non-htmx request", header: http.Header{}, expect: true},
+		{name: "htmx request", header: http.Header{"Hx-Request": {"true"}}, expect: false},
+		{
+			name:   "history restore request",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-History-Restore-Request": {"true"}},
+			expect: true,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := NeedsFullRender(r); actual != c.expect {
+				t.Errorf("NeedsFullRender() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestNeedsHead(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect bool
+	}{
+		{name: "non-htmx request", header: http.Header{}, expect: true},
+		{name: "targeted swap", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"main"}}, expect: false},
+		{name: "untargeted swap", header: http.Header{"Hx-Request": {"true"}}, expect: false},
+		{
+			name:   "history restore request",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-History-Restore-Request": {"true"}},
+			expect: true,
+		},
+		{
+			name:   "boosted navigation",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Boosted": {"true"}},
+			expect: true,
+		},
+		{
+			name:   "boosted navigation targeting body",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Boosted": {"true"}, "Hx-Target": {"body"}},
+			expect: true,
+		},
+		{
+			name:   "boosted targeted swap",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Boosted": {"true"}, "Hx-Target": {"main"}},
+			expect: false,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := NeedsHead(r); actual != c.expect {
+				t.Errorf("NeedsHead() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestWrapDocument(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect template.HTML
+	}{
+		{
+			name:   "full render",
+			header: http.Header{},
+			expect: "<!DOCTYPE html>\n<html>\n" +
+				`<head><meta charset="utf-8"><title>A &amp; B</title></head>` + "\n" +
+				"<body><p>hi</p></body>\n</html>\n",
+		},
+		{
+			name:   "fragment",
+			header: http.Header{"Hx-Request": {"true"}},
+			expect: "<p>hi</p>",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := WrapDocument(r, "A & B", "<p>hi</p>"); actual != c.expect {
+				t.Errorf("WrapDocument() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package htmx
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
 
 // RequestHeaders contains the headers set in an htmx request.
 //
@@ -17,12 +22,27 @@ type RequestHeaders struct {
 	HistoryRestoreRequest bool
 	// Prompt is the user response to an hx-prompt.
 	Prompt string
+	// PromptPresent indicates whether the HX-Prompt header was sent, which
+	// allows distinguishing an empty response from the absence of a prompt.
+	PromptPresent bool
 	// Target is the id of the target element, if it exists.
 	Target ID
+	// TargetPresent indicates whether the HX-Target header was sent, which
+	// allows distinguishing an absent header from an empty one.
+	TargetPresent bool
 	// TriggerName is the name of the triggered element if it exists.
 	TriggerName Element
+	// TriggerNamePresent indicates whether the HX-Trigger-Name header was
+	// sent, which allows distinguishing an absent header from an empty one.
+	TriggerNamePresent bool
 	// Trigger is the id of the triggered element if it exists.
 	Trigger ID
+	// TriggerPresent indicates whether the HX-Trigger header was sent, which
+	// allows distinguishing an absent header from an empty one.
+	TriggerPresent bool
+
+	// header are all headers of the request.
+	header http.Header
 }
 
 // Request returns the htmx [RequestHeaders] for the current request.
@@ -30,19 +50,391 @@ type RequestHeaders struct {
 // If the request was not made by htmx (as determined by the lack of the
 // "HX-Request" header), Request returns nil.
 //
+// To be robust against infrastructure altering header values, the boolean
+// headers HX-Request, HX-Boosted, and HX-History-Restore-Request are
+// considered true if their value is "true" or "1", ignoring case and
+// surrounding whitespace.
+// All other values, such as "false" or "0", are considered false.
+//
+// If the middleware bypassed the request, because it was created using
+// [WithBypass], Request always returns nil.
+//
 // This function works without the middleware in place.
 func Request(r *http.Request) *RequestHeaders {
-	if r.Header.Get("HX-Request") != "true" {
+	// the canonical form of HX-Request, so that Get doesn't allocate
+	if !headerBool(r.Header.Get("Hx-Request")) || r.Context().Value(bypassCtxKey{}) != nil {
 		return nil
 	}
 
 	return &RequestHeaders{
-		Boosted:               r.Header.Get("HX-Boosted") == "true",
+		Boosted:               headerBool(r.Header.Get("HX-Boosted")),
 		CurrentURL:            r.Header.Get("HX-Current-Url"),
-		HistoryRestoreRequest: r.Header.Get("HX-History-Restore-Request") == "true",
+		HistoryRestoreRequest: headerBool(r.Header.Get("HX-History-Restore-Request")),
 		Prompt:                r.Header.Get("HX-Prompt"),
+		PromptPresent:         headerPresent(r.Header, "HX-Prompt"),
 		Target:                r.Header.Get("HX-Target"),
+		TargetPresent:         headerPresent(r.Header, "HX-Target"),
 		TriggerName:           r.Header.Get("HX-Trigger-Name"),
+		TriggerNamePresent:    headerPresent(r.Header, "HX-Trigger-Name"),
 		Trigger:               r.Header.Get("HX-Trigger"),
+		TriggerPresent:        headerPresent(r.Header, "HX-Trigger"),
+		header:                r.Header,
+	}
+}
+
+// IsHTMX reports whether r was made by htmx.
+//
+// It is the same as checking whether [Request] returns nil.
+func IsHTMX(r *http.Request) bool {
+	return Request(r) != nil
+}
+
+// TargetIs reports whether r is an htmx request targeting the element with
+// the passed id.
+//
+// htmx sends the id of the target without a leading '#', but, for
+// convenience, id may be passed with one as well.
+//
+// If r is not an htmx request, or has no target, TargetIs reports false.
+func TargetIs(r *http.Request, id ID) bool {
+	req := Request(r)
+	if req == nil || req.Target == "" {
+		return false
+	}
+
+	return req.Target == strings.TrimPrefix(id, "#")
+}
+
+// TargetIn reports whether r is an htmx request targeting one of the elements
+// with the passed ids.
+//
+// Like for [TargetIs], ids may be passed with or without a leading '#'.
+func TargetIn(r *http.Request, ids ...ID) bool {
+	req := Request(r)
+	if req == nil || req.Target == "" {
+		return false
+	}
+
+	for _, id := range ids {
+		if req.Target == strings.TrimPrefix(id, "#") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBoosted reports whether the request is via an element using hx-boost.
+//
+// If h is nil, IsBoosted reports false, so that it is safe to call it on the
+// result of [Request] directly.
+func (h *RequestHeaders) IsBoosted() bool {
+	return h != nil && h.Boosted
+}
+
+// IsHistoryRestore reports whether the request is for history restoration
+// after a miss in the local history cache.
+//
+// If h is nil, IsHistoryRestore reports false, so that it is safe to call it
+// on the result of [Request] directly.
+func (h *RequestHeaders) IsHistoryRestore() bool {
+	return h != nil && h.HistoryRestoreRequest
+}
+
+func headerBool(val string) bool {
+	val = strings.TrimSpace(val)
+	return strings.EqualFold(val, "true") || val == "1"
+}
+
+func headerPresent(h http.Header, name string) bool {
+	return len(h.Values(name)) > 0
+}
+
+// PromptValue returns the user response to an hx-prompt, and whether there
+// was a prompt.
+//
+// The response is returned as is, see [RequestHeaders.TrimmedPromptValue]
+// for a version trimming whitespace.
+//
+// If h is nil, PromptValue returns false.
+func (h *RequestHeaders) PromptValue() (string, bool) {
+	if h == nil {
+		return "", false
+	}
+
+	return h.Prompt, h.PromptPresent
+}
+
+// TrimmedPromptValue is the same as [RequestHeaders.PromptValue], but trims
+// leading and trailing whitespace from the response.
+func (h *RequestHeaders) TrimmedPromptValue() (string, bool) {
+	prompt, ok := h.PromptValue()
+	return strings.TrimSpace(prompt), ok
+}
+
+// TriggerIDs returns the ids of the triggering elements.
+//
+// htmx itself only ever sends the id of a single element, so TriggerIDs will
+// usually return a slice containing just [RequestHeaders.Trigger].
+//
+// However, some extensions and custom setups send the ids of multiple
+// elements, separated by whitespace.
+// Since ids may not contain whitespace, this is the only separator that can
+// be split on safely.
+// Note that this is extension-specific behavior, and not part of htmx.
+//
+// If h is nil, or there is no triggering element, TriggerIDs returns nil, so
+// that it is safe to call it on the result of [Request] directly.
+func (h *RequestHeaders) TriggerIDs() []ID {
+	if h == nil {
+		return nil
+	}
+
+	ids := strings.Fields(h.Trigger)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return ids
+}
+
+// CurrentURLParsed parses [RequestHeaders.CurrentURL].
+//
+// If h is nil, or the current URL is absent, CurrentURLParsed returns nil
+// and no error.
+func (h *RequestHeaders) CurrentURLParsed() (*url.URL, error) {
+	if h == nil || h.CurrentURL == "" {
+		return nil, nil
+	}
+
+	return url.Parse(h.CurrentURL)
+}
+
+// ParentPath returns the path of the parent of [RequestHeaders.CurrentURL],
+// e.g. "/a" for "/a/b", and "/" for "/a".
+//
+// Trailing slashes are ignored, i.e. the parent of "/a/b/" is also "/a".
+//
+// If h is nil, or the current URL is absent, is the root, or can't be
+// parsed, ParentPath returns false.
+func (h *RequestHeaders) ParentPath() (string, bool) {
+	u, err := h.CurrentURLParsed()
+	if err != nil || u == nil {
+		return "", false
+	}
+
+	p := strings.TrimRight(u.Path, "/")
+	if p == "" {
+		return "", false
+	}
+
+	return path.Dir(p), true
+}
+
+// ScopeID scopes the passed id to the target of the request by prefixing it
+// with the target's id, separated by a dash, e.g. "cart-total" for the target
+// "cart" and the base id "total".
+//
+// If h is nil, or there is no target, base is returned as is, so that it is
+// safe to call ScopeID on the result of [Request] directly, e.g. when
+// rendering the full page.
+//
+// This allows the same fragment template to be swapped into multiple
+// targets on the same page, without its ids colliding.
+func (h *RequestHeaders) ScopeID(base ID) ID {
+	if h == nil || h.Target == "" {
+		return base
+	}
+
+	return h.Target + "-" + base
+}
+
+// TriggeredValue returns the name and the submitted value of the element
+// that triggered the request, e.g. the submit button that was clicked, by
+// looking up [RequestHeaders.TriggerName] in the body of r.
+//
+// If the field has multiple values, the first is returned.
+//
+// If h is nil, there is no named triggering element, or the body doesn't
+// contain a value for it, TriggeredValue returns false.
+func (h *RequestHeaders) TriggeredValue(r *http.Request) (name string, value string, ok bool) {
+	if h == nil || h.TriggerName == "" {
+		return "", "", false
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return "", "", false
+	}
+
+	vals := r.PostForm[h.TriggerName]
+	if len(vals) == 0 {
+		return "", "", false
+	}
+
+	return h.TriggerName, vals[0], true
+}
+
+// QueryParam returns the first value of the query parameter with the passed
+// name in [RequestHeaders.CurrentURL].
+//
+// This allows fragment handlers to read state kept in the URL of the page,
+// such as the active tab or filter.
+//
+// QueryParam returns false, if h is nil, if the current URL is absent or
+// can't be parsed, or if it doesn't contain the parameter.
+// A parameter that is present but empty, returns an empty string and true.
+func (h *RequestHeaders) QueryParam(name string) (string, bool) {
+	u, err := h.CurrentURLParsed()
+	if err != nil || u == nil {
+		return "", false
+	}
+
+	vals, ok := u.Query()[name]
+	if !ok || len(vals) == 0 {
+		return "", false
 	}
+
+	return vals[0], true
+}
+
+// MatchesRoute matches the path of [RequestHeaders.CurrentURL] against the
+// passed patterns, and returns the first pattern that matches.
+//
+// This allows fragment handlers to adapt to the page the user is currently
+// on.
+//
+// The patterns use the syntax of [http.ServeMux] patterns, e.g.
+// "/users/{id}", "/files/{path...}", "/docs/", or "/{$}".
+// Methods are ignored, and hosts are matched against the host of the current
+// URL.
+// Unlike http.ServeMux, MatchesRoute doesn't pick the most specific pattern,
+// so more specific patterns should be passed first.
+//
+// MatchesRoute returns false, if h is nil, if the current URL is absent or
+// can't be parsed, or if no pattern matches.
+func (h *RequestHeaders) MatchesRoute(patterns ...string) (matched string, ok bool) {
+	u, err := h.CurrentURLParsed()
+	if err != nil || u == nil {
+		return "", false
+	}
+
+	for _, pattern := range patterns {
+		if matchPattern(pattern, u) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// TargetsBody reports whether the request targets the body, i.e. whether the
+// response will effectively replace the whole page.
+//
+// This is assumed to be the case, if the target is "body", or if the request
+// is boosted and has no target, since boosted requests target the body by
+// default.
+//
+// If h is nil, TargetsBody reports false, so that it is safe to call it on
+// the result of [Request] directly.
+func (h *RequestHeaders) TargetsBody() bool {
+	return h != nil && (h.Target == "body" || (h.Target == "" && h.Boosted))
+}
+
+// TriggerAllowed reports whether the request was triggered by one of the
+// elements with the passed ids.
+//
+// If h is nil, or there is no triggering element, TriggerAllowed reports
+// false.
+//
+// This is a lightweight guard against requests made in the name of unknown
+// elements.
+// Since the header is set by the client, it is not a substitute for proper
+// authorization.
+func (h *RequestHeaders) TriggerAllowed(allowed ...ID) bool {
+	if h == nil || h.Trigger == "" {
+		return false
+	}
+
+	for _, id := range allowed {
+		if h.Trigger == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Names of the conventional headers read by [RequestHeaders.Timezone] and
+// [RequestHeaders.Locale].
+//
+// These are not part of htmx, and must be sent by the client, e.g. using
+// hx-headers.
+// Change them, if your application uses different names.
+var (
+	TimezoneHeader = "HX-Timezone"
+	LocaleHeader   = "HX-Locale"
+)
+
+// ClientHeader returns the value of the request header with the passed
+// name.
+//
+// This is intended for application-specific headers that the client sends
+// alongside the htmx headers, e.g. using hx-headers.
+//
+// If h is nil or the header is absent, ClientHeader returns an empty string.
+func (h *RequestHeaders) ClientHeader(name string) string {
+	if h == nil {
+		return ""
+	}
+
+	return h.header.Get(name)
+}
+
+// Timezone returns the value of the [TimezoneHeader], which by convention
+// contains the timezone of the browser, e.g. "Europe/Berlin".
+//
+// The client can send it by adding the following attribute to the body:
+//
+//	hx-headers='js:{"HX-Timezone": Intl.DateTimeFormat().resolvedOptions().timeZone}'
+//
+// If h is nil or the header is absent, Timezone returns an empty string.
+func (h *RequestHeaders) Timezone() string {
+	return h.ClientHeader(TimezoneHeader)
+}
+
+// Locale returns the value of the [LocaleHeader], which by convention
+// contains the locale of the browser, e.g. "de-DE".
+//
+// The client can send it by adding the following attribute to the body:
+//
+//	hx-headers='js:{"HX-Locale": navigator.language}'
+//
+// If h is nil or the header is absent, Locale returns an empty string.
+func (h *RequestHeaders) Locale() string {
+	return h.ClientHeader(LocaleHeader)
+}
+
+// Version returns the version of the edited resource, as sent by the client
+// in the header with the passed name, and whether the header is present.
+//
+// By convention, the client round-trips the version it received when
+// rendering the edit form, e.g. using hx-headers:
+//
+//	<form hx-put="/items/5" hx-headers='{"HX-Version": "7"}'>
+//
+// The server can then compare it with the current version to detect stale
+// submits, and respond using [VersionConflict].
+//
+// If h is nil, Version returns false.
+func (h *RequestHeaders) Version(headerName string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+
+	vals := h.header.Values(headerName)
+	if len(vals) == 0 {
+		return "", false
+	}
+
+	return vals[0], true
 }
@@ -0,0 +1,811 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequest(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		val    string
+		expect bool
+	}{
+		{name: "canonical", val: "true", expect: true},
+		{name: "upper case", val: "TRUE", expect: true},
+		{name: "title case", val: "True", expect: true},
+		{name: "one", val: "1", expect: true},
+		{name: "whitespace", val: " true ", expect: true},
+		{name: "empty", val: "", expect: false},
+		{name: "false", val: "false", expect: false},
+		{name: "zero", val: "0", expect: false},
+		{name: "yes", val: "yes", expect: false},
+		{name: "truthy prefix", val: "trueish", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("HX-Request", c.val)
+			if actual := Request(r) != nil; actual != c.expect {
+				t.Errorf("Request() != nil = %t, expected %t", actual, c.expect)
+			}
+
+			r.Header.Set("HX-Request", "true")
+			r.Header.Set("HX-Boosted", c.val)
+			r.Header.Set("HX-History-Restore-Request", c.val)
+
+			req := Request(r)
+			if req.Boosted != c.expect {
+				t.Errorf("Boosted = %t, expected %t", req.Boosted, c.expect)
+			}
+			if req.HistoryRestoreRequest != c.expect {
+				t.Errorf("HistoryRestoreRequest = %t, expected %t", req.HistoryRestoreRequest, c.expect)
+			}
+		})
+	}
+}
+
+func TestHeaderPresent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		vals   []string
+		expect bool
+	}{
+		{name: "absent", vals: nil, expect: false},
+		{name: "empty", vals: []string{""}, expect: true},
+		{name: "non-empty", vals: []string{"main"}, expect: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("HX-Request", "true")
+			for _, name := range []string{"Hx-Target", "Hx-Trigger", "Hx-Trigger-Name", "Hx-Prompt"} {
+				if c.vals != nil {
+					r.Header[name] = c.vals
+				}
+			}
+
+			if actual := headerPresent(r.Header, "HX-Target"); actual != c.expect {
+				t.Errorf("headerPresent() = %t, expected %t", actual, c.expect)
+			}
+
+			req := Request(r)
+			actual := []bool{req.TargetPresent, req.TriggerPresent, req.TriggerNamePresent, req.PromptPresent}
+			expect := []bool{c.expect, c.expect, c.expect, c.expect}
+			if !reflect.DeepEqual(actual, expect) {
+				t.Errorf("TargetPresent, TriggerPresent, TriggerNamePresent, PromptPresent = %v, expected %v",
+					actual, expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_TriggerIDs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		h      *RequestHeaders
+		expect []ID
+	}{
+		{name: "nil", h: nil, expect: nil},
+		{name: "no trigger", h: &RequestHeaders{}, expect: nil},
+		{name: "single id", h: &RequestHeaders{Trigger: "save"}, expect: []ID{"save"}},
+		{
+			name:   "multiple ids",
+			h:      &RequestHeaders{Trigger: " save  cancel\tdelete "},
+			expect: []ID{"save", "cancel", "delete"},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.h.TriggerIDs(); !reflect.DeepEqual(actual, c.expect) {
+				t.Errorf("TriggerIDs() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_ScopeID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		h      *RequestHeaders
+		base   ID
+		expect ID
+	}{
+		{name: "nil", h: nil, base: "total", expect: "total"},
+		{name: "no target", h: &RequestHeaders{}, base: "total", expect: "total"},
+		{name: "target", h: &RequestHeaders{Target: "cart"}, base: "total", expect: "cart-total"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.h.ScopeID(c.base); actual != c.expect {
+				t.Errorf("ScopeID(%q) = %q, expected %q", c.base, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_TargetsBody(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		h      *RequestHeaders
+		expect bool
+	}{
+		{name: "nil", h: nil, expect: false},
+		{name: "no target", h: &RequestHeaders{}, expect: false},
+		{name: "body target", h: &RequestHeaders{Target: "body"}, expect: true},
+		{name: "other target", h: &RequestHeaders{Target: "main"}, expect: false},
+		{name: "boosted without target", h: &RequestHeaders{Boosted: true}, expect: true},
+		{name: "boosted with target", h: &RequestHeaders{Boosted: true, Target: "main"}, expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.h.TargetsBody(); actual != c.expect {
+				t.Errorf("TargetsBody() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_ParentPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		h        *RequestHeaders
+		expect   string
+		expectOK bool
+	}{
+		{name: "nil", h: nil},
+		{name: "no current url", h: &RequestHeaders{}},
+		{name: "root", h: &RequestHeaders{CurrentURL: "http://example.com/"}},
+		{name: "invalid", h: &RequestHeaders{CurrentURL: "http://example.com/%zz"}},
+		{name: "first level", h: &RequestHeaders{CurrentURL: "http://example.com/a"}, expect: "/", expectOK: true},
+		{name: "second level", h: &RequestHeaders{CurrentURL: "http://example.com/a/b"}, expect: "/a", expectOK: true},
+		{name: "trailing slash", h: &RequestHeaders{CurrentURL: "http://example.com/a/b/"}, expect: "/a", expectOK: true},
+		{name: "query", h: &RequestHeaders{CurrentURL: "http://example.com/a/b?c=d"}, expect: "/a", expectOK: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, ok := c.h.ParentPath()
+			if actual != c.expect || ok != c.expectOK {
+				t.Errorf("ParentPath() = %q, %t, expected %q, %t", actual, ok, c.expect, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_TriggeredValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		h           *RequestHeaders
+		body        string
+		expectName  string
+		expectValue string
+		expectOK    bool
+	}{
+		{name: "nil", h: nil, body: "action=publish"},
+		{name: "no trigger name", h: &RequestHeaders{}, body: "action=publish"},
+		{
+			name:        "publish button",
+			h:           &RequestHeaders{TriggerName: "action"},
+			body:        "title=abc&action=publish",
+			expectName:  "action",
+			expectValue: "publish",
+			expectOK:    true,
+		},
+		{
+			name:        "draft button",
+			h:           &RequestHeaders{TriggerName: "action"},
+			body:        "title=abc&action=draft",
+			expectName:  "action",
+			expectValue: "draft",
+			expectOK:    true,
+		},
+		{
+			name:        "multiple values",
+			h:           &RequestHeaders{TriggerName: "action"},
+			body:        "action=a&action=b",
+			expectName:  "action",
+			expectValue: "a",
+			expectOK:    true,
+		},
+		{
+			name:       "empty value",
+			h:          &RequestHeaders{TriggerName: "action"},
+			body:       "action=",
+			expectName: "action",
+			expectOK:   true,
+		},
+		{name: "missing value", h: &RequestHeaders{TriggerName: "action"}, body: "title=abc"},
+		{name: "malformed body", h: &RequestHeaders{TriggerName: "action"}, body: "action=%zz"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			name, value, ok := c.h.TriggeredValue(r)
+			if name != c.expectName || value != c.expectValue || ok != c.expectOK {
+				t.Errorf("TriggeredValue() = %q, %q, %t, expected %q, %q, %t",
+					name, value, ok, c.expectName, c.expectValue, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_TriggerAllowed(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		h       *RequestHeaders
+		allowed []ID
+		expect  bool
+	}{
+		{name: "nil", h: nil, allowed: []ID{"delete"}, expect: false},
+		{name: "empty trigger", h: &RequestHeaders{}, allowed: []ID{"", "delete"}, expect: false},
+		{name: "allowed", h: &RequestHeaders{Trigger: "delete"}, allowed: []ID{"edit", "delete"}, expect: true},
+		{name: "disallowed", h: &RequestHeaders{Trigger: "forged"}, allowed: []ID{"edit", "delete"}, expect: false},
+		{name: "case mismatch", h: &RequestHeaders{Trigger: "Delete"}, allowed: []ID{"delete"}, expect: false},
+		{name: "no allowed ids", h: &RequestHeaders{Trigger: "delete"}, expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.h.TriggerAllowed(c.allowed...); actual != c.expect {
+				t.Errorf("TriggerAllowed(%q) = %t, expected %t", c.allowed, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_ClientHeader(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect string
+	}{
+		{name: "nil", header: http.Header{}, expect: ""},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}, expect: ""},
+		{name: "present", header: http.Header{"Hx-Request": {"true"}, "X-Tenant": {"abc"}}, expect: "abc"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := Request(r).ClientHeader("x-tenant"); actual != c.expect {
+				t.Errorf("ClientHeader() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_Timezone(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect string
+	}{
+		{name: "nil", header: http.Header{"Hx-Timezone": {"Europe/Berlin"}}, expect: ""},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}, expect: ""},
+		{
+			name:   "present",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Timezone": {"Europe/Berlin"}},
+			expect: "Europe/Berlin",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := Request(r).Timezone(); actual != c.expect {
+				t.Errorf("Timezone() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_Locale(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect string
+	}{
+		{name: "nil", header: http.Header{"Hx-Locale": {"de-DE"}}, expect: ""},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}, expect: ""},
+		{name: "present", header: http.Header{"Hx-Request": {"true"}, "Hx-Locale": {"de-DE"}}, expect: "de-DE"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := Request(r).Locale(); actual != c.expect {
+				t.Errorf("Locale() = %q, expected %q", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_CurrentURLParsed(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		h      *RequestHeaders
+		expect string
+	}{
+		{name: "nil", h: nil, expect: ""},
+		{name: "absent", h: &RequestHeaders{}, expect: ""},
+		{
+			name:   "absolute",
+			h:      &RequestHeaders{CurrentURL: "http://example.com/a?tab=b#c"},
+			expect: "http://example.com/a?tab=b#c",
+		},
+		{name: "relative", h: &RequestHeaders{CurrentURL: "/a?tab=b"}, expect: "/a?tab=b"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				u, err := c.h.CurrentURLParsed()
+				if err != nil {
+					t.Fatalf("CurrentURLParsed returned error: %v", err)
+				}
+
+				if c.expect == "" {
+					if u != nil {
+						t.Errorf("CurrentURLParsed() = %q, expected nil", u)
+					}
+					return
+				}
+
+				if u == nil {
+					t.Fatalf("CurrentURLParsed() = nil, expected %q", c.expect)
+				}
+				if actual := u.String(); actual != c.expect {
+					t.Errorf("CurrentURLParsed() = %q, expected %q", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		h := &RequestHeaders{CurrentURL: "http://example.com/%zz"}
+		if _, err := h.CurrentURLParsed(); err == nil {
+			t.Fatal("expected CurrentURLParsed to return an error")
+		}
+	})
+}
+
+func TestRequestHeaders_QueryParam(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		h           *RequestHeaders
+		expectValue string
+		expectOK    bool
+	}{
+		{name: "nil", h: nil},
+		{name: "absent current url", h: &RequestHeaders{}},
+		{name: "unparsable current url", h: &RequestHeaders{CurrentURL: "http://example.com/%zz?tab=a"}},
+		{name: "absent param", h: &RequestHeaders{CurrentURL: "http://example.com/?filter=a"}},
+		{
+			name:        "present param",
+			h:           &RequestHeaders{CurrentURL: "http://example.com/?tab=settings"},
+			expectValue: "settings",
+			expectOK:    true,
+		},
+		{
+			name:        "multiple values",
+			h:           &RequestHeaders{CurrentURL: "http://example.com/?tab=a&tab=b"},
+			expectValue: "a",
+			expectOK:    true,
+		},
+		{name: "empty param", h: &RequestHeaders{CurrentURL: "http://example.com/?tab="}, expectOK: true},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, ok := c.h.QueryParam("tab")
+			if value != c.expectValue || ok != c.expectOK {
+				t.Errorf("QueryParam() = %q, %t, expected %q, %t", value, ok, c.expectValue, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_Version(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		header        http.Header
+		expectVersion string
+		expectOK      bool
+	}{
+		{name: "nil", header: http.Header{"Hx-Version": {"7"}}},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}},
+		{
+			name:          "present",
+			header:        http.Header{"Hx-Request": {"true"}, "Hx-Version": {"7"}},
+			expectVersion: "7",
+			expectOK:      true,
+		},
+		{
+			name:     "empty",
+			header:   http.Header{"Hx-Request": {"true"}, "Hx-Version": {""}},
+			expectOK: true,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			version, ok := Request(r).Version("HX-Version")
+			if version != c.expectVersion || ok != c.expectOK {
+				t.Errorf("Version() = %q, %t, expected %q, %t", version, ok, c.expectVersion, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_MatchesRoute(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"/users/{id}/edit", "/users/{id}", "/{$}"}
+
+	testCases := []struct {
+		name          string
+		h             *RequestHeaders
+		expectMatched string
+		expectOK      bool
+	}{
+		{name: "nil", h: nil},
+		{name: "absent current url", h: &RequestHeaders{}},
+		{name: "unparsable current url", h: &RequestHeaders{CurrentURL: "http://example.com/%zz"}},
+		{
+			name:          "first match",
+			h:             &RequestHeaders{CurrentURL: "http://example.com/users/5/edit?tab=a"},
+			expectMatched: "/users/{id}/edit",
+			expectOK:      true,
+		},
+		{
+			name:          "later match",
+			h:             &RequestHeaders{CurrentURL: "http://example.com/users/5"},
+			expectMatched: "/users/{id}",
+			expectOK:      true,
+		},
+		{
+			name:          "root",
+			h:             &RequestHeaders{CurrentURL: "http://example.com/"},
+			expectMatched: "/{$}",
+			expectOK:      true,
+		},
+		{name: "no match", h: &RequestHeaders{CurrentURL: "http://example.com/items"}},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			matched, ok := c.h.MatchesRoute(patterns...)
+			if matched != c.expectMatched || ok != c.expectOK {
+				t.Errorf("MatchesRoute() = %q, %t, expected %q, %t", matched, ok, c.expectMatched, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestIsHTMX(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		expect bool
+	}{
+		{name: "htmx request", header: http.Header{"Hx-Request": {"true"}}, expect: true},
+		{name: "non-htmx request", header: http.Header{}, expect: false},
+		{name: "false", header: http.Header{"Hx-Request": {"false"}}, expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := IsHTMX(r); actual != c.expect {
+				t.Errorf("IsHTMX() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestTargetIs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		id     ID
+		expect bool
+	}{
+		{name: "match", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}}, id: "list", expect: true},
+		{name: "hash", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}}, id: "#list", expect: true},
+		{name: "mismatch", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}}, id: "form"},
+		{name: "no target", header: http.Header{"Hx-Request": {"true"}}, id: ""},
+		{name: "no target hash", header: http.Header{"Hx-Request": {"true"}}, id: "#"},
+		{name: "non-htmx request", header: http.Header{"Hx-Target": {"list"}}, id: "list"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := TargetIs(r, c.id); actual != c.expect {
+				t.Errorf("TargetIs(%q) = %t, expected %t", c.id, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestTargetIn(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		ids    []ID
+		expect bool
+	}{
+		{
+			name:   "match",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}},
+			ids:    []ID{"form", "list"},
+			expect: true,
+		},
+		{
+			name:   "hash",
+			header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}},
+			ids:    []ID{"#form", "#list"},
+			expect: true,
+		},
+		{name: "mismatch", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}}, ids: []ID{"form"}},
+		{name: "no ids", header: http.Header{"Hx-Request": {"true"}, "Hx-Target": {"list"}}},
+		{name: "no target", header: http.Header{"Hx-Request": {"true"}}, ids: []ID{"", "#"}},
+		{name: "non-htmx request", header: http.Header{"Hx-Target": {"list"}}, ids: []ID{"list"}},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			if actual := TargetIn(r, c.ids...); actual != c.expect {
+				t.Errorf("TargetIn(%q) = %t, expected %t", c.ids, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_IsBoosted(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		h      *RequestHeaders
+		expect bool
+	}{
+		{name: "nil", h: nil, expect: false},
+		{name: "boosted", h: &RequestHeaders{Boosted: true}, expect: true},
+		{name: "not boosted", h: &RequestHeaders{HistoryRestoreRequest: true}, expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.h.IsBoosted(); actual != c.expect {
+				t.Errorf("IsBoosted() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_IsHistoryRestore(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		h      *RequestHeaders
+		expect bool
+	}{
+		{name: "nil", h: nil, expect: false},
+		{name: "history restore", h: &RequestHeaders{HistoryRestoreRequest: true}, expect: true},
+		{name: "boosted", h: &RequestHeaders{Boosted: true}, expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.h.IsHistoryRestore(); actual != c.expect {
+				t.Errorf("IsHistoryRestore() = %t, expected %t", actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_PromptValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		header      http.Header
+		expectValue string
+		expectOK    bool
+	}{
+		{name: "nil", header: http.Header{"Hx-Prompt": {"abc"}}},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}},
+		{name: "empty", header: http.Header{"Hx-Request": {"true"}, "Hx-Prompt": {""}}, expectOK: true},
+		{
+			name:        "whitespace",
+			header:      http.Header{"Hx-Request": {"true"}, "Hx-Prompt": {"  "}},
+			expectValue: "  ",
+			expectOK:    true,
+		},
+		{
+			name:        "value",
+			header:      http.Header{"Hx-Request": {"true"}, "Hx-Prompt": {" abc "}},
+			expectValue: " abc ",
+			expectOK:    true,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			value, ok := Request(r).PromptValue()
+			if value != c.expectValue || ok != c.expectOK {
+				t.Errorf("PromptValue() = %q, %t, expected %q, %t", value, ok, c.expectValue, c.expectOK)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders_TrimmedPromptValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		header      http.Header
+		expectValue string
+		expectOK    bool
+	}{
+		{name: "nil", header: http.Header{"Hx-Prompt": {"abc"}}},
+		{name: "absent", header: http.Header{"Hx-Request": {"true"}}},
+		{name: "whitespace", header: http.Header{"Hx-Request": {"true"}, "Hx-Prompt": {"  "}}, expectOK: true},
+		{
+			name:        "value",
+			header:      http.Header{"Hx-Request": {"true"}, "Hx-Prompt": {" abc "}},
+			expectValue: "abc",
+			expectOK:    true,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = c.header
+
+			value, ok := Request(r).TrimmedPromptValue()
+			if value != c.expectValue || ok != c.expectOK {
+				t.Errorf("TrimmedPromptValue() = %q, %t, expected %q, %t", value, ok, c.expectValue, c.expectOK)
+			}
+		})
+	}
+}
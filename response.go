@@ -2,6 +2,8 @@ package htmx
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -73,6 +75,10 @@ type (
 		// The URL must be from the same origin as the request.
 		ReplaceURL SameOriginURL
 		// Reswap allows you to specify how the response will be swapped.
+		//
+		// If empty, the header is not sent.
+		// Note that this is different from SwapNone, which is sent as
+		// "none".
 		Reswap SwapStrategy
 		// Retarget is a CSS selector that updates the target of the content
 		// update to a different element on the page.
@@ -88,6 +94,31 @@ type (
 		TriggerAfterSettle map[Event]JSON
 		// TriggerAfterSwap triggers JSON after the swap step.
 		TriggerAfterSwap map[Event]JSON
+
+		// Extra are additional, non-htmx headers, such as X-App-Version, that
+		// are sent along with the htmx headers.
+		//
+		// Extra may be nil.
+		Extra http.Header
+
+		// OOB are the out-of-band swaps the response is intended to contain.
+		//
+		// Unlike the other fields, OOB is not sent as a header.
+		// Instead, it allows the rendering layer to read the intended swaps
+		// and annotate the rendered fragments accordingly.
+		OOB []OOBIntent
+
+		// err is the error accumulated by the chainable trigger methods.
+		err error
+		// appended are the last values of the events in Trigger written by
+		// TriggerAppend.
+		appended map[Event]JSON
+		// externalRedirect indicates that Redirect is intentionally not
+		// same-origin.
+		externalRedirect bool
+		// status is the status code written by the middleware, if the
+		// handler doesn't write one itself.
+		status int
 	}
 
 	// LocationHeader is a location used as the HX-Location response header.
@@ -114,6 +145,14 @@ type (
 	}
 )
 
+func newResponseHeaders() *ResponseHeaders {
+	return &ResponseHeaders{
+		Trigger:            make(map[Event]JSON),
+		TriggerAfterSettle: make(map[Event]JSON),
+		TriggerAfterSwap:   make(map[Event]JSON),
+	}
+}
+
 func (h *ResponseHeaders) AddHeaders(header http.Header) {
 	if h.Location.Path != "" {
 		header.Add("HX-Location", h.Location.HeaderValue())
@@ -148,6 +187,132 @@ func (h *ResponseHeaders) AddHeaders(header http.Header) {
 	if len(h.TriggerAfterSwap) > 0 {
 		header.Add("HX-Trigger-After-Swap", eventTriggersToHeaderValue(h.TriggerAfterSwap))
 	}
+	for name, vals := range h.Extra {
+		for _, val := range vals {
+			header.Add(name, val)
+		}
+	}
+}
+
+// modified reports whether any of the fields of h are set, i.e. whether h
+// differs from its initial state.
+func (h *ResponseHeaders) modified() bool {
+	loc := h.Location
+	return loc.Path != "" || loc.Source != "" || loc.Event != "" || loc.Handler != "" || loc.Target != "" ||
+		loc.Swap != "" || loc.Values != nil || len(loc.Headers) > 0 ||
+		h.PushURL != "" || h.Redirect != "" || h.Refresh || h.ReplaceURL != "" ||
+		h.Reswap != "" || h.Retarget != "" || h.Reselect != "" ||
+		len(h.Trigger) > 0 || len(h.TriggerAfterSettle) > 0 || len(h.TriggerAfterSwap) > 0 ||
+		len(h.Extra) > 0 || len(h.OOB) > 0
+}
+
+// Pairs returns the htmx headers, and the extra headers in
+// [ResponseHeaders.Extra], as a map of header names to their values.
+//
+// The returned pairs are exactly those that [ResponseHeaders.AddHeaders]
+// would add to an [http.Header].
+// This is useful for adapters for frameworks that don't use [http.Header].
+func (h *ResponseHeaders) Pairs() map[string][]string {
+	header := make(http.Header)
+	h.AddHeaders(header)
+	return header
+}
+
+// ToMap returns the htmx headers, and the extra headers in
+// [ResponseHeaders.Extra], as a map of header names to their values, like
+// [ResponseHeaders.Pairs], but with a single value per header.
+//
+// Each htmx header only has a single value.
+// Extra headers with multiple values are combined into a single value,
+// separated by ", ", as permitted for list-based HTTP header fields.
+// Like for an [http.Header], the header names are in canonical form, e.g.
+// "Hx-Trigger".
+//
+// This is useful to assert on the headers set by a handler in tests, without
+// running the middleware.
+func (h *ResponseHeaders) ToMap() map[string]string {
+	pairs := h.Pairs()
+
+	m := make(map[string]string, len(pairs))
+	for name, vals := range pairs {
+		m[name] = strings.Join(vals, ", ")
+	}
+
+	return m
+}
+
+// EffectiveSwap returns the swap strategy the client will use, given that it
+// would otherwise use defaultStrategy.
+//
+// Since HX-Reswap overrides the client's swap strategy, this is Reswap, if
+// set, and defaultStrategy otherwise.
+//
+// Note that the server can't know the strategy the client would use, as it
+// is determined by client-side attributes and configuration.
+// defaultStrategy must therefore be supplied by the caller.
+func (h *ResponseHeaders) EffectiveSwap(defaultStrategy SwapStrategy) SwapStrategy {
+	if h.Reswap != "" {
+		return h.Reswap
+	}
+
+	return defaultStrategy
+}
+
+// TriggerE triggers the passed event as soon as the response is received,
+// and returns h to allow chaining.
+//
+// If data can't be marshalled to json, the event is not triggered, and the
+// error is recorded instead.
+// Errors accumulate over subsequent calls and can be retrieved by calling
+// [ResponseHeaders.Err].
+// Calls following a failed call are still executed.
+func (h *ResponseHeaders) TriggerE(name Event, data any) *ResponseHeaders {
+	h.setTriggerE(h.Trigger, name, data)
+	return h
+}
+
+// TriggerAfterSettleE triggers the passed event after the settling step, and
+// returns h to allow chaining.
+//
+// Errors are recorded like for [ResponseHeaders.TriggerE].
+func (h *ResponseHeaders) TriggerAfterSettleE(name Event, data any) *ResponseHeaders {
+	h.setTriggerE(h.TriggerAfterSettle, name, data)
+	return h
+}
+
+// TriggerAfterSwapE triggers the passed event after the swap step, and
+// returns h to allow chaining.
+//
+// Errors are recorded like for [ResponseHeaders.TriggerE].
+func (h *ResponseHeaders) TriggerAfterSwapE(name Event, data any) *ResponseHeaders {
+	h.setTriggerE(h.TriggerAfterSwap, name, data)
+	return h
+}
+
+func (h *ResponseHeaders) setTriggerE(ts map[Event]JSON, name Event, data any) {
+	jsonData, err := marshalTriggerData(data)
+	if err != nil {
+		h.err = errors.Join(h.err, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+
+	ts[name] = jsonData
+}
+
+// Err returns the errors accumulated by the chainable trigger methods, such
+// as [ResponseHeaders.TriggerE], joined using [errors.Join].
+//
+// If no errors occurred, Err returns nil.
+func (h *ResponseHeaders) Err() error {
+	return h.err
+}
+
+func marshalTriggerData(data any) (JSON, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(data)
 }
 
 func (loc *LocationHeader) HeaderValue() string {
@@ -165,10 +330,35 @@ func (loc *LocationHeader) HeaderValue() string {
 }
 
 func eventTriggersToHeaderValue(ts map[Event]JSON) string {
+	val, err := BuildTriggerHeader(ts)
+	if err != nil {
+		panic(err) // this should never happen
+	}
+	return val
+}
+
+// BuildTriggerHeader builds the value of an HX-Trigger,
+// HX-Trigger-After-Settle, or HX-Trigger-After-Swap header from the passed
+// triggers, as done by the middleware.
+//
+// If none of the triggers have data, the value is a comma-separated list of
+// the events.
+// Otherwise, it is a JSON object mapping the events to their data.
+// If triggers is empty, BuildTriggerHeader returns an empty string.
+//
+// This is useful to reuse the encoding outside of responses, e.g. for
+// logging.
+//
+// An error is returned, if the data of a trigger is not valid JSON.
+func BuildTriggerHeader(triggers map[Event]JSON) (string, error) {
+	if len(triggers) == 0 {
+		return "", nil
+	}
+
 	var eventLen int
 
 	var hasData bool
-	for event, data := range ts {
+	for event, data := range triggers {
 		eventLen += len(event) + len(",")
 		if data != nil {
 			hasData = true
@@ -176,20 +366,20 @@ func eventTriggersToHeaderValue(ts map[Event]JSON) string {
 	}
 
 	if hasData {
-		data, err := json.Marshal(ts)
+		data, err := json.Marshal(triggers)
 		if err != nil {
-			panic(err) // this should never happen
+			return "", err
 		}
-		return string(data)
+		return string(data), nil
 	}
 
 	var b strings.Builder
 	b.Grow(eventLen - 1) // minus one comma that we don't need
-	for event := range ts {
+	for event := range triggers {
 		if b.Len() > 0 {
 			b.WriteByte(',')
 		}
 		b.WriteString(event)
 	}
-	return b.String()
+	return b.String(), nil
 }
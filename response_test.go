@@ -0,0 +1,293 @@
+package htmx
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestResponseHeaders_Pairs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    func(h *ResponseHeaders)
+		expect map[string][]string
+	}{
+		{
+			name:   "empty",
+			set:    func(*ResponseHeaders) {},
+			expect: map[string][]string{},
+		},
+		{
+			name: "htmx headers",
+			set: func(h *ResponseHeaders) {
+				h.Location = LocationHeader{Path: "/a", Target: "#main"}
+				h.PushURL = "/b"
+				h.Refresh = true
+				h.Retarget = "#main"
+				h.Trigger["a"] = nil
+				h.TriggerAfterSwap["b"] = JSON("1")
+			},
+			expect: map[string][]string{
+				"Hx-Location":           {`{"path":"/a","target":"#main"}`},
+				"Hx-Push-Url":           {"/b"},
+				"Hx-Refresh":            {"true"},
+				"Hx-Retarget":           {"#main"},
+				"Hx-Trigger":            {"a"},
+				"Hx-Trigger-After-Swap": {`{"b":1}`},
+			},
+		},
+		{
+			name: "extra headers",
+			set: func(h *ResponseHeaders) {
+				h.Reselect = "#content"
+				h.Extra = http.Header{"X-Tags": {"a", "b"}}
+			},
+			expect: map[string][]string{
+				"Hx-Reselect": {"#content"},
+				"X-Tags":      {"a", "b"},
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newResponseHeaders()
+			c.set(h)
+
+			actual := h.Pairs()
+			if !reflect.DeepEqual(actual, c.expect) {
+				t.Errorf("Pairs() = %v, expected %v", actual, c.expect)
+			}
+
+			header := make(http.Header)
+			h.AddHeaders(header)
+			if !reflect.DeepEqual(map[string][]string(header), actual) {
+				t.Errorf("Pairs() = %v, expected it to match AddHeaders %v", actual, header)
+			}
+		})
+	}
+}
+
+func TestResponseHeaders_ToMap(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		set    func(h *ResponseHeaders)
+		expect map[string]string
+	}{
+		{
+			name:   "empty",
+			set:    func(*ResponseHeaders) {},
+			expect: map[string]string{},
+		},
+		{
+			name: "htmx headers",
+			set: func(h *ResponseHeaders) {
+				h.Retarget = "#main"
+				h.Reswap = SwapOuterHTML
+				h.Trigger["a"] = nil
+			},
+			expect: map[string]string{
+				"Hx-Retarget": "#main",
+				"Hx-Reswap":   "outerHTML",
+				"Hx-Trigger":  "a",
+			},
+		},
+		{
+			name: "all htmx headers",
+			set: func(h *ResponseHeaders) {
+				h.Location = LocationHeader{Path: "/a", Target: "#main"}
+				h.PushURL = "/b"
+				h.Redirect = "/c"
+				h.Refresh = true
+				h.ReplaceURL = "/d"
+				h.Reswap = SwapNone
+				h.Retarget = "#main"
+				h.Reselect = "#content"
+				h.Trigger["a"] = JSON(`{"b":1}`)
+				h.TriggerAfterSettle["c"] = nil
+				h.TriggerAfterSwap["d"] = JSON("1")
+			},
+			expect: map[string]string{
+				"Hx-Location":             `{"path":"/a","target":"#main"}`,
+				"Hx-Push-Url":             "/b",
+				"Hx-Redirect":             "/c",
+				"Hx-Refresh":              "true",
+				"Hx-Replace-Url":          "/d",
+				"Hx-Reswap":               "none",
+				"Hx-Retarget":             "#main",
+				"Hx-Reselect":             "#content",
+				"Hx-Trigger":              `{"a":{"b":1}}`,
+				"Hx-Trigger-After-Settle": "c",
+				"Hx-Trigger-After-Swap":   `{"d":1}`,
+			},
+		},
+		{
+			name: "extra headers",
+			set: func(h *ResponseHeaders) {
+				h.Extra = http.Header{
+					"X-App-Version": {"1.2.3"},
+					"X-Tags":        {"a", "b"},
+				}
+			},
+			expect: map[string]string{
+				"X-App-Version": "1.2.3",
+				"X-Tags":        "a, b",
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newResponseHeaders()
+			c.set(h)
+
+			actual := h.ToMap()
+			if !reflect.DeepEqual(actual, c.expect) {
+				t.Errorf("ToMap() = %v, expected %v", actual, c.expect)
+			}
+
+			header := make(http.Header)
+			h.AddHeaders(header)
+			if len(actual) != len(header) {
+				t.Errorf("ToMap() = %v, expected the same headers as AddHeaders %v", actual, header)
+			}
+			for name, vals := range header {
+				if expect := strings.Join(vals, ", "); actual[name] != expect {
+					t.Errorf("ToMap()[%q] = %q, expected it to match AddHeaders %q", name, actual[name], expect)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseHeaders_EffectiveSwap(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		reswap          SwapStrategy
+		defaultStrategy SwapStrategy
+		expect          SwapStrategy
+	}{
+		{name: "default", defaultStrategy: SwapInnerHTML, expect: SwapInnerHTML},
+		{name: "reswap", reswap: SwapOuterHTML, defaultStrategy: SwapInnerHTML, expect: SwapOuterHTML},
+		{name: "reswap none", reswap: SwapNone, defaultStrategy: SwapInnerHTML, expect: SwapNone},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newResponseHeaders()
+			h.Reswap = c.reswap
+
+			if actual := h.EffectiveSwap(c.defaultStrategy); actual != c.expect {
+				t.Errorf("EffectiveSwap(%q) = %q, expected %q", c.defaultStrategy, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestResponseHeaders_TriggerE(t *testing.T) {
+	t.Parallel()
+
+	h := newResponseHeaders().
+		TriggerE("a", nil).
+		TriggerE("b", func() {}).
+		TriggerAfterSettleE("c", 1).
+		TriggerAfterSwapE("d", "e").
+		TriggerAfterSwapE("f", make(chan int))
+
+	expect := map[string]map[Event]JSON{
+		"Trigger":            {"a": nil},
+		"TriggerAfterSettle": {"c": JSON("1")},
+		"TriggerAfterSwap":   {"d": JSON(`"e"`)},
+	}
+	actual := map[string]map[Event]JSON{
+		"Trigger":            h.Trigger,
+		"TriggerAfterSettle": h.TriggerAfterSettle,
+		"TriggerAfterSwap":   h.TriggerAfterSwap,
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Errorf("triggers = %s, expected %s", actual, expect)
+	}
+
+	err := h.Err()
+	if err == nil {
+		t.Fatal("expected Err to return an error")
+	}
+	for _, name := range []string{"b: ", "f: "} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Err() = %q, expected it to contain the error of %q", err, strings.TrimSuffix(name, ": "))
+		}
+	}
+
+	if err := newResponseHeaders().TriggerE("a", 1).Err(); err != nil {
+		t.Errorf("Err() = %v, expected nil", err)
+	}
+}
+
+func TestBuildTriggerHeader(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name     string
+		triggers map[Event]JSON
+		expect   string
+	}{
+		{name: "nil", triggers: nil, expect: ""},
+		{name: "empty", triggers: map[Event]JSON{}, expect: ""},
+		{name: "single event", triggers: map[Event]JSON{"a": nil}, expect: "a"},
+		{name: "multiple events", triggers: map[Event]JSON{"a": nil, "b": nil, "c": nil}, expect: "a,b,c"},
+		{name: "data", triggers: map[Event]JSON{"a": JSON(`{"b":1}`)}, expect: `{"a":{"b":1}}`},
+		{name: "mixed", triggers: map[Event]JSON{"a": nil, "b": JSON("1")}, expect: `{"a":null,"b":1}`},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				actual, err := BuildTriggerHeader(c.triggers)
+				if err != nil {
+					t.Fatalf("BuildTriggerHeader returned error: %v", err)
+				}
+
+				// events without data are not ordered
+				if !strings.HasPrefix(actual, "{") {
+					events := strings.Split(actual, ",")
+					sort.Strings(events)
+					actual = strings.Join(events, ",")
+				}
+
+				if actual != c.expect {
+					t.Errorf("BuildTriggerHeader() = %q, expected %q", actual, c.expect)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := BuildTriggerHeader(map[Event]JSON{"a": JSON("{")}); err == nil {
+			t.Error("expected BuildTriggerHeader to return an error")
+		}
+	})
+}
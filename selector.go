@@ -0,0 +1,161 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IDSelector returns a selector selecting the element with the passed id.
+//
+// The id is escaped, so that ids that are not valid CSS identifiers, e.g.
+// because they start with a digit, can be selected as well.
+func IDSelector(id ID) Selector {
+	return "#" + cssEscape(id)
+}
+
+// ComponentSelector returns a selector selecting the element with the id
+// formed by joining prefix and name with a dash, e.g. "#cart-total" for the
+// prefix "cart" and the name "total".
+//
+// Like with [IDSelector], the id is escaped.
+func ComponentSelector(prefix, name string) Selector {
+	return IDSelector(prefix + "-" + name)
+}
+
+// cssEscape escapes s for use as a CSS identifier, as done by the CSS.escape
+// function of browsers.
+//
+// See: https://drafts.csswg.org/cssom/#serialize-an-identifier
+func cssEscape(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i, c := range s {
+		switch {
+		case c == 0:
+			b.WriteRune('\uFFFD')
+		case (c >= 0x01 && c <= 0x1F) || c == 0x7F,
+			c >= '0' && c <= '9' && (i == 0 || (i == 1 && s[0] == '-')):
+			b.WriteByte('\\')
+			b.WriteString(strconv.FormatInt(int64(c), 16))
+			b.WriteByte(' ')
+		case i == 0 && c == '-' && len(s) == 1:
+			b.WriteString("\\-")
+		case c >= 0x80 || c == '-' || c == '_' ||
+			(c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			b.WriteRune(c)
+		default:
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// This is the extended selector selecting the element itself.
+//
+// See: https://htmx.org/docs/#extended-css-selectors
+const This Selector = "this"
+
+// Closest returns the extended selector selecting the closest ancestor
+// matching sel, or the element itself, if it matches.
+//
+// See: https://htmx.org/docs/#extended-css-selectors
+func Closest(sel Selector) Selector {
+	return "closest " + sel
+}
+
+// Find returns the extended selector selecting the first child element
+// matching sel.
+//
+// See: https://htmx.org/docs/#extended-css-selectors
+func Find(sel Selector) Selector {
+	return "find " + sel
+}
+
+// Next returns the extended selector selecting the next element matching
+// sel.
+// If sel is empty, the next sibling element is selected.
+//
+// See: https://htmx.org/docs/#extended-css-selectors
+func Next(sel Selector) Selector {
+	if sel == "" {
+		return "next"
+	}
+	return "next " + sel
+}
+
+// Previous returns the extended selector selecting the previous element
+// matching sel.
+// If sel is empty, the previous sibling element is selected.
+//
+// See: https://htmx.org/docs/#extended-css-selectors
+func Previous(sel Selector) Selector {
+	if sel == "" {
+		return "previous"
+	}
+	return "previous " + sel
+}
+
+// validateSelector performs basic sanity checks on sel.
+//
+// It is not a full CSS parser, and only catches obvious mistakes, i.e. blank
+// selectors, unbalanced brackets, parentheses, and quotes, and characters
+// that can't appear unescaped in a selector.
+func validateSelector(sel Selector) error {
+	if strings.TrimSpace(sel) == "" {
+		return errors.New("selector is empty")
+	}
+
+	var (
+		open    []rune // unclosed brackets and parentheses
+		quote   rune   // the quote of the current string, if any
+		escaped bool
+	)
+	for _, c := range sel {
+		if (c < ' ' && !strings.ContainsRune("\t\n\f\r", c)) || c == 0x7f {
+			return fmt.Errorf("invalid control character %q", c)
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			open = append(open, c)
+		case c == ')' || c == ']':
+			want := '('
+			if c == ']' {
+				want = '['
+			}
+
+			if len(open) == 0 || open[len(open)-1] != want {
+				return fmt.Errorf("unbalanced %q", c)
+			}
+			open = open[:len(open)-1]
+		case c == '{' || c == '}' || c == ';':
+			return fmt.Errorf("invalid character %q", c)
+		}
+	}
+
+	switch {
+	case escaped:
+		return errors.New("unterminated escape")
+	case quote != 0:
+		return fmt.Errorf("unterminated string starting with %q", quote)
+	case len(open) > 0:
+		return fmt.Errorf("unclosed %q", open[len(open)-1])
+	}
+
+	return nil
+}
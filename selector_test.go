@@ -0,0 +1,215 @@
+package htmx
+
+import "testing"
+
+func TestIDSelector(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		id     ID
+		expect Selector
+	}{
+		{name: "identifier", id: "email", expect: "#email"},
+		{name: "leading digit", id: "5", expect: `#\35 `},
+		{name: "special characters", id: "user.name", expect: `#user\.name`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := IDSelector(c.id); actual != c.expect {
+				t.Errorf("IDSelector(%q) = %q, expected %q", c.id, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestComponentSelector(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		prefix, part string
+		expect       Selector
+	}{
+		{name: "identifiers", prefix: "cart", part: "total", expect: "#cart-total"},
+		{name: "leading digit", prefix: "5", part: "total", expect: `#\35 -total`},
+		{name: "special characters", prefix: "user.list", part: "item:5", expect: `#user\.list-item\:5`},
+		{name: "spaces", prefix: "a b", part: "c", expect: `#a\ b-c`},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := ComponentSelector(c.prefix, c.part); actual != c.expect {
+				t.Errorf("ComponentSelector(%q, %q) = %q, expected %q", c.prefix, c.part, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestCSSEscape(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		in     string
+		expect string
+	}{
+		{in: "", expect: ""},
+		{in: "email", expect: "email"},
+		{in: "a-b_c", expect: "a-b_c"},
+		{in: "--a", expect: "--a"},
+		{in: "_a", expect: "_a"},
+		{in: "a5", expect: "a5"},
+		{in: "5a", expect: `\35 a`},
+		{in: "-5a", expect: `-\35 a`},
+		{in: "-", expect: `\-`},
+		{in: "a.b", expect: `a\.b`},
+		{in: "a b", expect: `a\ b`},
+		{in: "a:b", expect: `a\:b`},
+		{in: `a"b`, expect: `a\"b`},
+		{in: "a\x00b", expect: "a\uFFFDb"},
+		{in: "a\x01b", expect: `a\1 b`},
+		{in: "a\x7fb", expect: `a\7f b`},
+		{in: "über", expect: "über"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.in, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := cssEscape(c.in); actual != c.expect {
+				t.Errorf("cssEscape(%q) = %q, expected %q", c.in, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestClosest(t *testing.T) {
+	t.Parallel()
+
+	if actual := Closest("tr"); actual != "closest tr" {
+		t.Errorf("Closest() = %q, expected %q", actual, "closest tr")
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	if actual := Find(".error"); actual != "find .error" {
+		t.Errorf("Find() = %q, expected %q", actual, "find .error")
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		sel    Selector
+		expect Selector
+	}{
+		{name: "selector", sel: "li", expect: "next li"},
+		{name: "sibling", sel: "", expect: "next"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := Next(c.sel); actual != c.expect {
+				t.Errorf("Next(%q) = %q, expected %q", c.sel, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		sel    Selector
+		expect Selector
+	}{
+		{name: "selector", sel: "li", expect: "previous li"},
+		{name: "sibling", sel: "", expect: "previous"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := Previous(c.sel); actual != c.expect {
+				t.Errorf("Previous(%q) = %q, expected %q", c.sel, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	t.Parallel()
+
+	successCases := []Selector{
+		"#main",
+		".item > li:nth-child(2n+1)",
+		`a[href="/a]b"]`,
+		`[data-a='(']`,
+		`#a\:b`,
+		"#a,\n#b",
+		"closest tr",
+	}
+
+	failureCases := []Selector{
+		"",
+		" \t\n",
+		"a[",
+		"a]",
+		"li:not(.a",
+		"li:not(.a])",
+		`a[href="b]`,
+		`#a\`,
+		"#a{color:red}",
+		"#a;",
+		"#a\x00",
+		"#a\x7f",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c, func(t *testing.T) {
+				t.Parallel()
+
+				if err := validateSelector(c); err != nil {
+					t.Errorf("validateSelector(%q) = %v, expected no error", c, err)
+				}
+			})
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c, func(t *testing.T) {
+				t.Parallel()
+
+				if err := validateSelector(c); err == nil {
+					t.Errorf("expected validateSelector(%q) to return an error", c)
+				}
+			})
+		}
+	})
+}
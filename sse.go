@@ -0,0 +1,115 @@
+package htmx
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SSEWriter writes server-sent events, as consumed by htmx's sse extension.
+//
+// See: https://htmx.org/extensions/server-sent-events
+type SSEWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// NewSSEWriter creates a new [SSEWriter] writing to w.
+//
+// It sets the Content-Type and Cache-Control headers required for
+// server-sent events.
+func NewSSEWriter(w http.ResponseWriter) *SSEWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	return &SSEWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+// Swap sends the passed HTML as an event with the passed name, and flushes
+// it to the client.
+//
+// The sse extension swaps the HTML into all elements listening for the event
+// using sse-swap="<event>".
+// Which element is swapped, is therefore decided client-side, e.g. by using
+// hx-target on the listening element:
+//
+//	<div hx-ext="sse" sse-connect="/events">
+//	    <div sse-swap="new-message" hx-target="#messages" hx-swap="beforeend"></div>
+//	</div>
+//
+// target is informational only and may be empty.
+// If set, it is sent as an SSE comment preceding the event, e.g.
+// ": target #messages", which clients ignore, but which makes the intended
+// target visible when debugging the stream.
+func (w *SSEWriter) Swap(event Event, target Selector, html template.HTML) error {
+	var comment string
+	if target != "" {
+		comment = "target " + target
+	}
+
+	if err := w.writeEvent(comment, event, string(html)); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// SendEvent sends an event with the passed name and data, and flushes it to
+// the client.
+//
+// data is marshalled to json, the same way as for [Trigger].
+// If data is nil, the event is sent with empty data.
+//
+// An error is returned, if data can't be marshalled to json, or if writing
+// fails.
+func (w *SSEWriter) SendEvent(name Event, data any) error {
+	jsonData, err := marshalTriggerData(data)
+	if err != nil {
+		return err
+	}
+
+	if err := w.writeEvent("", name, string(jsonData)); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// Flush flushes all buffered events to the client.
+func (w *SSEWriter) Flush() error {
+	return w.rc.Flush()
+}
+
+var sseLineBreakReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// writeEvent writes an event with the passed name and data, preceded by the
+// passed comment, if it is not empty.
+//
+// Line breaks in data are split into multiple data lines, and line breaks in
+// comment and event, which would break the framing, are replaced by spaces.
+func (w *SSEWriter) writeEvent(comment string, event Event, data string) error {
+	var b strings.Builder
+	if comment != "" {
+		b.WriteString(": ")
+		b.WriteString(sseLineBreakReplacer.Replace(comment))
+		b.WriteByte('\n')
+	}
+
+	if event != "" {
+		b.WriteString("event: ")
+		b.WriteString(sseLineBreakReplacer.Replace(event))
+		b.WriteByte('\n')
+	}
+
+	data = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data)
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
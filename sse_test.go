@@ -0,0 +1,148 @@
+package htmx
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewSSEWriter(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	NewSSEWriter(rec)
+
+	if actual := rec.Header().Get("Content-Type"); actual != "text/event-stream" {
+		t.Errorf("Content-Type = %q, expected %q", actual, "text/event-stream")
+	}
+	if actual := rec.Header().Get("Cache-Control"); actual != "no-cache" {
+		t.Errorf("Cache-Control = %q, expected %q", actual, "no-cache")
+	}
+}
+
+func TestSSEWriter_Swap(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		event  Event
+		target Selector
+		html   template.HTML
+		expect string
+	}{
+		{
+			name:   "single line",
+			event:  "new-message",
+			html:   "<p>hi</p>",
+			expect: "event: new-message\ndata: <p>hi</p>\n\n",
+		},
+		{
+			name:   "multiple lines",
+			event:  "new-message",
+			html:   "<ul>\n<li>a</li>\r\n<li>b</li>\r</ul>",
+			expect: "event: new-message\ndata: <ul>\ndata: <li>a</li>\ndata: <li>b</li>\ndata: </ul>\n\n",
+		},
+		{
+			name:   "empty html",
+			event:  "clear",
+			expect: "event: clear\ndata: \n\n",
+		},
+		{
+			name:   "no event",
+			html:   "<p>hi</p>",
+			expect: "data: <p>hi</p>\n\n",
+		},
+		{
+			name:   "target",
+			event:  "new-message",
+			target: "#messages",
+			html:   "<p>hi</p>",
+			expect: ": target #messages\nevent: new-message\ndata: <p>hi</p>\n\n",
+		},
+		{
+			name:   "line breaks in event and target",
+			event:  "new\nmessage",
+			target: "#a,\r\n#b",
+			html:   "<p>hi</p>",
+			expect: ": target #a, #b\nevent: new message\ndata: <p>hi</p>\n\n",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			if err := NewSSEWriter(rec).Swap(c.event, c.target, c.html); err != nil {
+				t.Fatalf("Swap returned error: %v", err)
+			}
+
+			if actual := rec.Body.String(); actual != c.expect {
+				t.Errorf("body = %q, expected %q", actual, c.expect)
+			}
+			if !rec.Flushed {
+				t.Error("expected Swap to flush")
+			}
+		})
+	}
+}
+
+func TestSSEWriter_SendEvent(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name   string
+		event  Event
+		data   any
+		expect string
+	}{
+		{name: "nil", event: "ping", data: nil, expect: "event: ping\ndata: \n\n"},
+		{name: "string", event: "toast", data: "Saved", expect: "event: toast\ndata: \"Saved\"\n\n"},
+		{
+			name:   "object",
+			event:  "update",
+			data:   map[string]any{"id": 5, "html": "<p>a</p>"},
+			expect: "event: update\ndata: {\"html\":\"\\u003cp\\u003ea\\u003c/p\\u003e\",\"id\":5}\n\n",
+		},
+		{name: "json", event: "update", data: JSON(`{"id":5}`), expect: "event: update\ndata: {\"id\":5}\n\n"},
+		{name: "no event", data: 1, expect: "data: 1\n\n"},
+		{name: "line break in event", event: "a\nb", data: 1, expect: "event: a b\ndata: 1\n\n"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				rec := httptest.NewRecorder()
+				if err := NewSSEWriter(rec).SendEvent(c.event, c.data); err != nil {
+					t.Fatalf("SendEvent returned error: %v", err)
+				}
+
+				if actual := rec.Body.String(); actual != c.expect {
+					t.Errorf("body = %q, expected %q", actual, c.expect)
+				}
+				if !rec.Flushed {
+					t.Error("expected SendEvent to flush")
+				}
+			})
+		}
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		if err := NewSSEWriter(rec).SendEvent("update", func() {}); err == nil {
+			t.Fatal("expected SendEvent to return an error")
+		}
+
+		if rec.Body.Len() > 0 {
+			t.Errorf("body = %q, expected it to be empty", rec.Body.String())
+		}
+	})
+}
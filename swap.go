@@ -0,0 +1,160 @@
+package htmx
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScrollPosition is the position used by the scroll and show swap modifiers.
+type ScrollPosition string
+
+const (
+	ScrollTop    ScrollPosition = "top"
+	ScrollBottom ScrollPosition = "bottom"
+)
+
+// Show is the value of the show swap modifier.
+type Show string
+
+// ShowTarget returns a [Show] showing the passed position of the element
+// matching sel.
+//
+// If sel is empty, the target of the swap is used.
+func ShowTarget(sel Selector, pos ScrollPosition) Show {
+	if sel == "" {
+		return Show(pos)
+	}
+
+	return Show(sel + ":" + string(pos))
+}
+
+// ShowWindow returns a [Show] showing the passed position of the window.
+func ShowWindow(pos ScrollPosition) Show {
+	return Show("window:" + string(pos))
+}
+
+// swapModifiers are the keys of the known swap modifiers in the order they
+// are rendered in.
+var swapModifiers = [...]string{"swap", "settle", "transition", "scroll", "show", "ignoreTitle", "focus-scroll"}
+
+// WithSwapDelay returns a copy of s with the swap modifier set, which
+// delays the swap by d after the response was received.
+//
+// d is rendered in milliseconds.
+func (s SwapStrategy) WithSwapDelay(d time.Duration) SwapStrategy {
+	return s.withModifier("swap", formatDuration(d))
+}
+
+// WithSettleDelay returns a copy of s with the settle modifier set, which
+// determines the delay between the swap and the settle step.
+//
+// d is rendered in milliseconds.
+func (s SwapStrategy) WithSettleDelay(d time.Duration) SwapStrategy {
+	return s.withModifier("settle", formatDuration(d))
+}
+
+// WithTransition returns a copy of s with the transition modifier set,
+// which determines whether the View Transitions API is used for the swap.
+//
+// To animate specific elements using a named view transition, assign them a
+// view-transition-name, e.g. using [ViewTransitionNameAttr].
+//
+// See: https://htmx.org/docs/#view-transitions
+func (s SwapStrategy) WithTransition(transition bool) SwapStrategy {
+	return s.withModifier("transition", strconv.FormatBool(transition))
+}
+
+// WithScroll returns a copy of s with the scroll modifier set, which
+// scrolls the target to the passed position after the swap.
+func (s SwapStrategy) WithScroll(pos ScrollPosition) SwapStrategy {
+	return s.withModifier("scroll", string(pos))
+}
+
+// WithShow returns a copy of s with the show modifier set, which scrolls the
+// viewport, so that the passed position of an element becomes visible.
+//
+// Use [ShowTarget] and [ShowWindow] to create the [Show], e.g.:
+//
+//	htmx.SwapOuterHTML.WithShow(htmx.ShowTarget("#row-5", htmx.ScrollTop))
+func (s SwapStrategy) WithShow(show Show) SwapStrategy {
+	return s.withModifier("show", string(show))
+}
+
+// WithIgnoreTitle returns a copy of s with the ignoreTitle modifier set,
+// which determines whether a <title> element in the response is ignored,
+// instead of updating the document's title.
+func (s SwapStrategy) WithIgnoreTitle(ignoreTitle bool) SwapStrategy {
+	return s.withModifier("ignoreTitle", strconv.FormatBool(ignoreTitle))
+}
+
+// WithFocusScroll returns a copy of s with the focus-scroll modifier set,
+// which determines whether the browser scrolls to a focused element after
+// the swap.
+func (s SwapStrategy) WithFocusScroll(focusScroll bool) SwapStrategy {
+	return s.withModifier("focus-scroll", strconv.FormatBool(focusScroll))
+}
+
+// withModifier returns a copy of s with the modifier with the passed key set
+// to val, replacing any previous value.
+//
+// Known modifiers are rendered in the order of swapModifiers, followed by
+// unknown modifiers in the order they appear in s.
+func (s SwapStrategy) withModifier(key, val string) SwapStrategy {
+	fields := strings.Fields(string(s))
+	if len(fields) == 0 {
+		return SwapStrategy(key + ":" + val)
+	}
+
+	var (
+		style   = fields[0]
+		known   = make(map[string]string, len(swapModifiers))
+		unknown []string
+	)
+	if strings.Contains(style, ":") { // no explicit strategy, only modifiers
+		style = ""
+	} else {
+		fields = fields[1:]
+	}
+
+	for _, f := range fields {
+		k, _, _ := strings.Cut(f, ":")
+		if isSwapModifier(k) {
+			known[k] = f
+		} else {
+			unknown = append(unknown, f)
+		}
+	}
+	known[key] = key + ":" + val
+
+	var b strings.Builder
+	b.WriteString(style)
+	for _, k := range swapModifiers {
+		if f, ok := known[k]; ok {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(f)
+		}
+	}
+	for _, f := range unknown {
+		b.WriteByte(' ')
+		b.WriteString(f)
+	}
+
+	return SwapStrategy(b.String())
+}
+
+func isSwapModifier(key string) bool {
+	for _, m := range swapModifiers {
+		if m == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatDuration(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
@@ -0,0 +1,222 @@
+package htmx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSwapStrategy_WithTransition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		s          SwapStrategy
+		transition bool
+		expect     SwapStrategy
+	}{
+		{name: "enable", s: SwapInnerHTML, transition: true, expect: "innerHTML transition:true"},
+		{name: "disable", s: SwapOuterHTML, transition: false, expect: "outerHTML transition:false"},
+		{name: "empty", s: "", transition: true, expect: "transition:true"},
+		{name: "replace", s: "innerHTML transition:false", transition: true, expect: "innerHTML transition:true"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.s.WithTransition(c.transition); actual != c.expect {
+				t.Errorf("%q.WithTransition(%t) = %q, expected %q", c.s, c.transition, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSwapStrategy_withModifier(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		s      SwapStrategy
+		key    string
+		val    string
+		expect SwapStrategy
+	}{
+		{name: "empty", s: "", key: "swap", val: "1ms", expect: "swap:1ms"},
+		{name: "bare strategy", s: SwapInnerHTML, key: "swap", val: "1ms", expect: "innerHTML swap:1ms"},
+		{name: "only modifiers", s: "settle:2ms", key: "swap", val: "1ms", expect: "swap:1ms settle:2ms"},
+		{name: "replace", s: "innerHTML swap:1ms", key: "swap", val: "2ms", expect: "innerHTML swap:2ms"},
+		{
+			name:   "canonical order",
+			s:      "outerHTML focus-scroll:false scroll:top",
+			key:    "swap",
+			val:    "1ms",
+			expect: "outerHTML swap:1ms scroll:top focus-scroll:false",
+		},
+		{
+			name:   "unknown modifiers last",
+			s:      "innerHTML custom:a settle:2ms",
+			key:    "swap",
+			val:    "1ms",
+			expect: "innerHTML swap:1ms settle:2ms custom:a",
+		},
+		{
+			name:   "extra whitespace",
+			s:      "  innerHTML   settle:2ms ",
+			key:    "swap",
+			val:    "1ms",
+			expect: "innerHTML swap:1ms settle:2ms",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.s.withModifier(c.key, c.val); actual != c.expect {
+				t.Errorf("%q.withModifier(%q, %q) = %q, expected %q", c.s, c.key, c.val, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSwapStrategy_builder(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		s      SwapStrategy
+		expect SwapStrategy
+	}{
+		{name: "no modifiers", s: SwapInnerHTML, expect: "innerHTML"},
+		{name: "swap delay", s: SwapInnerHTML.WithSwapDelay(200 * time.Millisecond), expect: "innerHTML swap:200ms"},
+		{name: "settle delay", s: SwapInnerHTML.WithSettleDelay(time.Second), expect: "innerHTML settle:1000ms"},
+		{name: "scroll", s: SwapOuterHTML.WithScroll(ScrollBottom), expect: "outerHTML scroll:bottom"},
+		{
+			name:   "show",
+			s:      SwapOuterHTML.WithShow(ShowTarget("#item", ScrollBottom)),
+			expect: "outerHTML show:#item:bottom",
+		},
+		{name: "ignore title", s: SwapInnerHTML.WithIgnoreTitle(true), expect: "innerHTML ignoreTitle:true"},
+		{name: "focus scroll", s: SwapInnerHTML.WithFocusScroll(false), expect: "innerHTML focus-scroll:false"},
+		{
+			name: "all modifiers",
+			s: SwapInnerHTML.
+				WithFocusScroll(false).
+				WithIgnoreTitle(true).
+				WithShow(ShowWindow(ScrollTop)).
+				WithScroll(ScrollTop).
+				WithTransition(true).
+				WithSettleDelay(100 * time.Millisecond).
+				WithSwapDelay(200 * time.Millisecond),
+			expect: "innerHTML swap:200ms settle:100ms transition:true scroll:top show:window:top " +
+				"ignoreTitle:true focus-scroll:false",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if c.s != c.expect {
+				t.Errorf("swap strategy = %q, expected %q", c.s, c.expect)
+			}
+			if !c.s.valid() {
+				t.Errorf("expected %q to be valid", c.s)
+			}
+		})
+	}
+}
+
+func TestShowTarget(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sel    Selector
+		pos    ScrollPosition
+		expect Show
+	}{
+		{sel: "#row-5", pos: ScrollTop, expect: "#row-5:top"},
+		{sel: ".item", pos: ScrollBottom, expect: ".item:bottom"},
+		{sel: "", pos: ScrollTop, expect: "top"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(string(c.sel)+" "+string(c.pos), func(t *testing.T) {
+			t.Parallel()
+
+			if actual := ShowTarget(c.sel, c.pos); actual != c.expect {
+				t.Errorf("ShowTarget(%q, %q) = %q, expected %q", c.sel, c.pos, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestShowWindow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pos    ScrollPosition
+		expect Show
+	}{
+		{pos: ScrollTop, expect: "window:top"},
+		{pos: ScrollBottom, expect: "window:bottom"},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(string(c.pos), func(t *testing.T) {
+			t.Parallel()
+
+			if actual := ShowWindow(c.pos); actual != c.expect {
+				t.Errorf("ShowWindow(%q) = %q, expected %q", c.pos, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSwapStrategy_WithShow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		s      SwapStrategy
+		show   Show
+		expect SwapStrategy
+	}{
+		{name: "target", s: SwapOuterHTML, show: ShowTarget("#row-5", ScrollTop), expect: "outerHTML show:#row-5:top"},
+		{name: "window", s: SwapInnerHTML, show: ShowWindow(ScrollTop), expect: "innerHTML show:window:top"},
+		{name: "swap target", s: SwapInnerHTML, show: ShowTarget("", ScrollBottom), expect: "innerHTML show:bottom"},
+		{
+			name:   "replace",
+			s:      "outerHTML show:window:top",
+			show:   ShowTarget("#row-5", ScrollBottom),
+			expect: "outerHTML show:#row-5:bottom",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := c.s.WithShow(c.show); actual != c.expect {
+				t.Errorf("%q.WithShow(%q) = %q, expected %q", c.s, c.show, actual, c.expect)
+			}
+		})
+	}
+
+	t.Run("reswap", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRequest()
+		Reswap(r, SwapOuterHTML.WithShow(ShowTarget("#row-5", ScrollTop)))
+
+		if actual := headers(r).Get("HX-Reswap"); actual != "outerHTML show:#row-5:top" {
+			t.Errorf("HX-Reswap = %q, expected %q", actual, "outerHTML show:#row-5:top")
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrCrossOrigin is the error wrapped by errors reporting a URL that is not of
+// the same origin as the request.
+var ErrCrossOrigin = errors.New("url is not same-origin")
+
+// sameOrigin reports whether u is of the same origin as r.
+//
+// Relative URLs and the "false" sentinel used by HX-Push-Url and
+// HX-Replace-Url are always considered same-origin.
+// Absolute URLs must use http or https, but only their host is compared to
+// that of the request.
+// The scheme of the request can't be determined reliably, e.g. because TLS
+// is usually terminated by a proxy, which would otherwise cause same-host
+// https URLs to be rejected.
+//
+// Since browsers treat backslashes like slashes, and strip surrounding
+// whitespace, URLs that contain backslashes or are surrounded by whitespace
+// are never considered same-origin.
+// Otherwise, e.g. "/\evil.com" would be navigated to as "//evil.com".
+// For the same reason, relative URLs starting with "//" but without a host,
+// e.g. "///evil.com", are rejected as well.
+func sameOrigin(r *http.Request, u string) bool {
+	if u == "false" {
+		return true
+	}
+
+	if strings.ContainsRune(u, '\\') || strings.TrimSpace(u) != u {
+		return false
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+
+	if parsed.Scheme == "" && parsed.Host == "" && parsed.Opaque == "" {
+		return !strings.HasPrefix(u, "//")
+	}
+
+	if parsed.Scheme != "" && !strings.EqualFold(parsed.Scheme, "http") && !strings.EqualFold(parsed.Scheme, "https") {
+		return false
+	}
+
+	return parsed.Host != "" && strings.EqualFold(parsed.Host, r.Host)
+}
+
+// samePathAndQuery reports whether a and b have the same path and query.
+//
+// Trailing slashes, except for the root path, and the order of query
+// parameters are ignored.
+// If either URL can't be parsed, samePathAndQuery reports false.
+func samePathAndQuery(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+
+	return normalizePath(ua.Path) == normalizePath(ub.Path) && ua.Query().Encode() == ub.Query().Encode()
+}
+
+func normalizePath(p string) string {
+	p = strings.TrimRight(p, "/")
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// matchPattern reports whether u matches the passed pattern, which uses the
+// syntax of [http.ServeMux] patterns.
+//
+// Methods are ignored, as they can't be matched against a URL.
+func matchPattern(pattern string, u *url.URL) bool {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimLeft(rest, " \t")
+	}
+
+	if !strings.HasPrefix(pattern, "/") {
+		i := strings.IndexByte(pattern, '/')
+		if i < 0 || !strings.EqualFold(pattern[:i], u.Host) {
+			return false
+		}
+		pattern = pattern[i:]
+	}
+
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+
+	patternSegs := strings.Split(pattern[1:], "/")
+	pathSegs := strings.Split(p[1:], "/")
+
+	for i, ps := range patternSegs {
+		if i == len(patternSegs)-1 {
+			switch {
+			case ps == "": // trailing slash, matches all paths with this prefix
+				return len(pathSegs) >= i
+			case ps == "{$}":
+				return len(pathSegs) == i+1 && pathSegs[i] == ""
+			case strings.HasPrefix(ps, "{") && strings.HasSuffix(ps, "...}"):
+				return len(pathSegs) > i
+			}
+		}
+
+		if i >= len(pathSegs) {
+			return false
+		}
+
+		if strings.HasPrefix(ps, "{") && strings.HasSuffix(ps, "}") {
+			if pathSegs[i] == "" {
+				return false
+			}
+		} else if ps != pathSegs[i] {
+			return false
+		}
+	}
+
+	return len(pathSegs) == len(patternSegs)
+}
@@ -0,0 +1,137 @@
+package htmx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSameOrigin(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		tls    bool
+		u      string
+		expect bool
+	}{
+		{name: "false sentinel", u: "false", expect: true},
+		{name: "relative path", u: "/a/b?c=d#e", expect: true},
+		{name: "relative path without slash", u: "a/b", expect: true},
+		{name: "query only", u: "?page=2", expect: true},
+		{name: "absolute same origin", u: "http://example.com/a", expect: true},
+		{name: "absolute same origin tls", tls: true, u: "https://example.com/a", expect: true},
+		{name: "absolute case insensitive", u: "HTTP://EXAMPLE.COM/a", expect: true},
+		{name: "scheme-relative same host", u: "//example.com/a", expect: true},
+		{name: "scheme-relative other host", u: "//evil.com", expect: false},
+		{name: "triple slash", u: "///evil.com", expect: false},
+		{name: "absolute other host", u: "http://evil.com/a", expect: false},
+		{name: "absolute https without tls", u: "https://example.com/a", expect: true},
+		{name: "absolute http with tls", tls: true, u: "http://example.com/a", expect: true},
+		{name: "absolute other scheme", u: "ftp://example.com/a", expect: false},
+		{name: "absolute other port", u: "http://example.com:8080/a", expect: false},
+		{name: "backslash", u: `/\evil.com`, expect: false},
+		{name: "double backslash", u: `\\evil.com`, expect: false},
+		{name: "backslash in path", u: `/a\b`, expect: false},
+		{name: "backslash in userinfo", u: `http://example.com\@evil.com`, expect: false},
+		{name: "leading whitespace", u: " //evil.com", expect: false},
+		{name: "control character", u: "/\t/evil.com", expect: false},
+		{name: "javascript", u: "javascript:alert(1)", expect: false},
+		{name: "data", u: "data:text/html,hi", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			if c.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+
+			if actual := sameOrigin(r, c.u); actual != c.expect {
+				t.Errorf("sameOrigin(%q) = %t, expected %t", c.u, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestSamePathAndQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		a, b   string
+		expect bool
+	}{
+		{name: "equal", a: "/a?b=c", b: "/a?b=c", expect: true},
+		{name: "absolute and relative", a: "http://example.com/a?b=c", b: "/a?b=c", expect: true},
+		{name: "trailing slash", a: "/a/", b: "/a", expect: true},
+		{name: "root", a: "http://example.com", b: "/", expect: true},
+		{name: "query order", a: "/a?b=1&c=2", b: "/a?c=2&b=1", expect: true},
+		{name: "fragment", a: "/a#b", b: "/a", expect: true},
+		{name: "different path", a: "/a", b: "/b", expect: false},
+		{name: "different query", a: "/a?b=1", b: "/a?b=2", expect: false},
+		{name: "missing query", a: "/a?b=1", b: "/a", expect: false},
+		{name: "unparsable", a: "/a", b: "%zz", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := samePathAndQuery(c.a, c.b); actual != c.expect {
+				t.Errorf("samePathAndQuery(%q, %q) = %t, expected %t", c.a, c.b, actual, c.expect)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern string
+		u       string
+		expect  bool
+	}{
+		{pattern: "/users/{id}", u: "http://example.com/users/5", expect: true},
+		{pattern: "/users/{id}", u: "http://example.com/users/", expect: false},
+		{pattern: "/users/{id}", u: "http://example.com/users/5/edit", expect: false},
+		{pattern: "/users/{id}/edit", u: "http://example.com/users/5/edit", expect: true},
+		{pattern: "GET /users/{id}", u: "http://example.com/users/5", expect: true},
+		{pattern: "/files/{path...}", u: "http://example.com/files/a/b", expect: true},
+		{pattern: "/files/{path...}", u: "http://example.com/files/", expect: true},
+		{pattern: "/files/{path...}", u: "http://example.com/files", expect: false},
+		{pattern: "/docs/", u: "http://example.com/docs/a/b", expect: true},
+		{pattern: "/docs/", u: "http://example.com/other", expect: false},
+		{pattern: "/{$}", u: "http://example.com/", expect: true},
+		{pattern: "/{$}", u: "http://example.com", expect: true},
+		{pattern: "/{$}", u: "http://example.com/a", expect: false},
+		{pattern: "/", u: "http://example.com/a", expect: true},
+		{pattern: "/a", u: "http://example.com/a", expect: true},
+		{pattern: "/a", u: "http://example.com/b", expect: false},
+		{pattern: "example.com/a", u: "http://EXAMPLE.com/a", expect: true},
+		{pattern: "example.org/a", u: "http://example.com/a", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.pattern+" "+c.u, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(c.u)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := matchPattern(c.pattern, u); actual != c.expect {
+				t.Errorf("matchPattern(%q, %q) = %t, expected %t", c.pattern, c.u, actual, c.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package htmx_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mavolin/go-htmx"
+)
+
+func TestResponseWriterWrapper(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		write   func(w http.ResponseWriter) error
+		flushed bool
+	}{
+		{
+			name: "write",
+			write: func(w http.ResponseWriter) error {
+				_, err := io.WriteString(w, "abc")
+				return err
+			},
+		},
+		{
+			name: "write header",
+			write: func(w http.ResponseWriter) error {
+				w.WriteHeader(http.StatusOK)
+				return nil
+			},
+		},
+		{
+			name: "flush",
+			write: func(w http.ResponseWriter) error {
+				return http.NewResponseController(w).Flush()
+			},
+			flushed: true,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := htmx.NewResponseWriterWrapper(rec, r)
+
+			htmx.Retarget(r, "#main")
+			if htmx.WroteHXHeader(w) {
+				t.Fatal("htmx headers written before the first write")
+			}
+
+			if err := c.write(w); err != nil {
+				t.Fatal(err)
+			}
+
+			if !htmx.WroteHXHeader(w) {
+				t.Fatal("htmx headers not written after the first write")
+			}
+
+			// headers set after the first write are not sent anymore
+			htmx.Reselect(r, "#content")
+			if err := c.write(w); err != nil {
+				t.Fatal(err)
+			}
+
+			// Result returns the headers as they were, when the status was
+			// written
+			res := rec.Result()
+			defer res.Body.Close()
+
+			if actual := res.Header.Values("HX-Retarget"); len(actual) != 1 || actual[0] != "#main" {
+				t.Errorf("HX-Retarget = %q, expected exactly one value %q", actual, "#main")
+			}
+			if actual := res.Header.Get("HX-Reselect"); actual != "" {
+				t.Errorf("HX-Reselect = %q, expected it to be absent", actual)
+			}
+			if actual := rec.Header().Values("HX-Retarget"); len(actual) != 1 {
+				t.Errorf("HX-Retarget was written %d times, expected once", len(actual))
+			}
+			if rec.Flushed != c.flushed {
+				t.Errorf("flushed = %t, expected %t", rec.Flushed, c.flushed)
+			}
+		})
+	}
+}
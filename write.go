@@ -0,0 +1,203 @@
+package htmx
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// ResponseOption is a func applied to the response headers of a request,
+// before the response is written.
+//
+// Typically, it is a closure calling one or more setters:
+//
+//	func(r *http.Request) error {
+//	    htmx.Retarget(r, "#main")
+//	    return htmx.Trigger(r, "reload-nav", nil)
+//	}
+type ResponseOption func(r *http.Request) error
+
+// NoSwap responds to the request without swapping any content.
+//
+// It sets HX-Reswap to [SwapNone], applies the passed options, e.g. closures
+// calling [Trigger], and then writes a 200 status with an empty body.
+// The htmx headers are written along with the status.
+//
+// This is useful for actions that don't require a DOM change but still need
+// to trigger events, such as marking an item as read.
+// Writing an empty body without setting HX-Reswap, would otherwise swap an
+// empty string into the target.
+//
+// If one of the options returns an error, NoSwap returns that error without
+// writing anything.
+func NoSwap(w http.ResponseWriter, r *http.Request, opts ...ResponseOption) error {
+	Reswap(r, SwapNone)
+
+	if err := applyResponseOptions(r, opts); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// WriteEmpty writes a 200 status with an empty body and a Content-Length of
+// 0.
+//
+// This is useful for responses that only consist of htmx headers, e.g.
+// triggers, which are written along with the status.
+// Setting Content-Length explicitly makes such responses well-formed for
+// strict proxies, that reject empty chunked bodies.
+func WriteEmpty(w http.ResponseWriter) {
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusOK)
+}
+
+// NoContent writes a 204 No Content status.
+//
+// This is the idiomatic response for handlers that only need to set htmx
+// headers, e.g. triggers, which are written along with the status:
+//
+//	htmx.Trigger(r, "refreshList", nil)
+//	htmx.NoContent(w)
+//
+// Note that htmx doesn't swap the content of 204 responses, i.e. the target
+// is left unchanged.
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Encode responds to r using one of two representations.
+//
+// For htmx requests, htmlFn is called and the returned HTML is written with
+// a text/html content type.
+// The htmx headers are written along with it, as usual.
+// For all other requests, jsonFn is called, and its result is encoded as
+// JSON with an application/json content type.
+//
+// If the called func returns an error, Encode returns that error without
+// writing anything.
+func Encode(
+	w http.ResponseWriter, r *http.Request, htmlFn func() (template.HTML, error), jsonFn func() (any, error),
+) error {
+	if IsHTMX(r) {
+		html, err := htmlFn()
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err = io.WriteString(w, string(html))
+		return err
+	}
+
+	v, err := jsonFn()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	return err
+}
+
+// RespondError responds to r with the passed error status code, and
+// triggers [ErrorEvent] with detail as the event's detail.
+//
+// htmx fires htmx:responseError for 4xx and 5xx responses, and doesn't swap
+// their content by default.
+// The triggered event allows the client to display the structured error
+// detail anyway:
+//
+//	document.body.addEventListener("showError", (evt) => {
+//	    showToast(evt.detail.message);
+//	});
+//
+// status must be a client or server error, i.e. 4xx or 5xx.
+//
+// An error is returned, if status is not an error status, or if detail
+// can't be marshalled to json.
+// In that case nothing is written.
+func RespondError(w http.ResponseWriter, r *http.Request, status int, detail any) error {
+	if status < 400 || status > 599 {
+		return fmt.Errorf("htmx: %d is not an error status", status)
+	}
+
+	if err := Trigger(r, ErrorEvent, detail); err != nil {
+		return err
+	}
+
+	w.WriteHeader(status)
+	return nil
+}
+
+// Respond responds to r with the passed main content, followed by the
+// out-of-band swaps in oob, which may be nil.
+//
+// Before anything is written, the passed options, e.g. closures calling
+// [Trigger], are applied.
+// If one of them returns an error, Respond returns that error without
+// writing anything.
+//
+// The htmx headers are written along with the content.
+func Respond(
+	w http.ResponseWriter, r *http.Request, main template.HTML, oob *OOBSet, opts ...ResponseOption,
+) error {
+	if err := applyResponseOptions(r, opts); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := io.WriteString(w, string(main+oob.HTML()))
+	return err
+}
+
+// OOBOnly responds to r with only the out-of-band swaps in oob.
+//
+// It sets HX-Reswap to [SwapNone], since htmx would otherwise swap the
+// remaining, empty, content into the target, blanking it.
+//
+// The htmx headers are written along with the content.
+func OOBOnly(w http.ResponseWriter, r *http.Request, oob *OOBSet) error {
+	Reswap(r, SwapNone)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := io.WriteString(w, string(oob.HTML()))
+	return err
+}
+
+// Write responds to r with the passed status and fragment.
+//
+// Before anything is written, the passed options, e.g. closures calling
+// [Retarget], are applied.
+// If one of them returns an error, Write returns that error without writing
+// anything.
+//
+// The htmx headers are written along with the status.
+func Write(w http.ResponseWriter, r *http.Request, status int, fragment template.HTML, opts ...ResponseOption) error {
+	if err := applyResponseOptions(r, opts); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := io.WriteString(w, string(fragment))
+	return err
+}
+
+func applyResponseOptions(r *http.Request, opts []ResponseOption) error {
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
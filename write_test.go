@@ -0,0 +1,453 @@
+package htmx
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// unwrittenWriter is an http.ResponseWriter that fails the test, if anything
+// is written to it.
+type unwrittenWriter struct {
+	t      *testing.T
+	header http.Header
+}
+
+func newUnwrittenWriter(t *testing.T) *unwrittenWriter {
+	return &unwrittenWriter{t: t, header: make(http.Header)}
+}
+
+func (w *unwrittenWriter) Header() http.Header { return w.header }
+
+func (w *unwrittenWriter) Write(data []byte) (int, error) {
+	w.t.Errorf("unexpected write of %q", data)
+	return len(data), nil
+}
+
+func (w *unwrittenWriter) WriteHeader(statusCode int) {
+	w.t.Errorf("unexpected status %d", statusCode)
+}
+
+func TestNoSwap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+			err := NoSwap(w, r, func(r *http.Request) error {
+				return Trigger(r, "itemRead", nil)
+			})
+			if err != nil {
+				t.Errorf("NoSwap returned error: %v", err)
+			}
+		})
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, expected %d", rec.Code, http.StatusOK)
+		}
+		if rec.Body.Len() > 0 {
+			t.Errorf("body = %q, expected it to be empty", rec.Body.String())
+		}
+		if actual := rec.Header().Get("HX-Reswap"); actual != "none" {
+			t.Errorf("HX-Reswap = %q, expected %q", actual, "none")
+		}
+		if actual := rec.Header().Get("HX-Trigger"); actual != "itemRead" {
+			t.Errorf("HX-Trigger = %q, expected %q", actual, "itemRead")
+		}
+	})
+
+	t.Run("option error", func(t *testing.T) {
+		t.Parallel()
+
+		expectErr := errors.New("abc")
+
+		err := NoSwap(newUnwrittenWriter(t), newTestRequest(), func(*http.Request) error { return expectErr })
+		if !errors.Is(err, expectErr) {
+			t.Errorf("NoSwap() = %v, expected %v", err, expectErr)
+		}
+	})
+}
+
+func TestWriteEmpty(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+		TriggerEvent(r, "saved")
+		WriteEmpty(w)
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusOK)
+	}
+	if actual := rec.Header().Get("Content-Length"); actual != "0" {
+		t.Errorf("Content-Length = %q, expected %q", actual, "0")
+	}
+	if actual := rec.Header().Get("HX-Trigger"); actual != "saved" {
+		t.Errorf("HX-Trigger = %q, expected %q", actual, "saved")
+	}
+	if rec.Body.Len() > 0 {
+		t.Errorf("body = %q, expected it to be empty", rec.Body.String())
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+		_ = Trigger(r, "refreshList", nil)
+		NoContent(w)
+
+		// headers set after the status was written are not sent anymore
+		Retarget(r, "#list")
+	})
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusNoContent)
+	}
+	if actual := rec.Header().Get("HX-Trigger"); actual != "refreshList" {
+		t.Errorf("HX-Trigger = %q, expected %q", actual, "refreshList")
+	}
+	if actual := rec.Header().Get("HX-Retarget"); actual != "" {
+		t.Errorf("HX-Retarget = %q, expected it to be absent", actual)
+	}
+	if rec.Body.Len() > 0 {
+		t.Errorf("body = %q, expected it to be empty", rec.Body.String())
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name              string
+		htmx              bool
+		expectContentType string
+		expectBody        string
+	}{
+		{name: "htmx request", htmx: true, expectContentType: "text/html; charset=utf-8", expectBody: "<p>a</p>"},
+		{name: "non-htmx request", htmx: false, expectContentType: "application/json", expectBody: `{"a":1}`},
+	}
+
+	htmlFn := func() (template.HTML, error) { return "<p>a</p>", nil }
+	jsonFn := func() (any, error) { return map[string]int{"a": 1}, nil }
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				if c.htmx {
+					r.Header.Set("HX-Request", "true")
+				}
+
+				rec := httptest.NewRecorder()
+				if err := Encode(rec, r, htmlFn, jsonFn); err != nil {
+					t.Fatalf("Encode returned error: %v", err)
+				}
+
+				if actual := rec.Header().Get("Content-Type"); actual != c.expectContentType {
+					t.Errorf("Content-Type = %q, expected %q", actual, c.expectContentType)
+				}
+				if actual := rec.Body.String(); actual != c.expectBody {
+					t.Errorf("body = %q, expected %q", actual, c.expectBody)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name   string
+		htmx   bool
+		htmlFn func() (template.HTML, error)
+		jsonFn func() (any, error)
+	}{
+		{
+			name:   "html error",
+			htmx:   true,
+			htmlFn: func() (template.HTML, error) { return "", errors.New("abc") },
+			jsonFn: jsonFn,
+		},
+		{
+			name:   "json error",
+			htmlFn: htmlFn,
+			jsonFn: func() (any, error) { return nil, errors.New("abc") },
+		},
+		{
+			name:   "json marshal error",
+			htmlFn: htmlFn,
+			jsonFn: func() (any, error) { return func() {}, nil },
+		},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				if c.htmx {
+					r.Header.Set("HX-Request", "true")
+				}
+
+				if err := Encode(newUnwrittenWriter(t), r, c.htmlFn, c.jsonFn); err == nil {
+					t.Error("expected Encode to return an error")
+				}
+			})
+		}
+	})
+}
+
+func TestRespondError(t *testing.T) {
+	t.Parallel()
+
+	successCases := []struct {
+		name          string
+		status        int
+		detail        any
+		expectTrigger string
+	}{
+		{
+			name:          "client error",
+			status:        http.StatusUnprocessableEntity,
+			detail:        map[string]string{"message": "invalid email"},
+			expectTrigger: `{"showError":{"message":"invalid email"}}`,
+		},
+		{
+			name:          "server error",
+			status:        http.StatusInternalServerError,
+			detail:        "abc",
+			expectTrigger: `{"showError":"abc"}`,
+		},
+		{name: "nil detail", status: http.StatusNotFound, expectTrigger: "showError"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range successCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+					if err := RespondError(w, r, c.status, c.detail); err != nil {
+						t.Errorf("RespondError returned error: %v", err)
+					}
+				})
+
+				if rec.Code != c.status {
+					t.Errorf("status = %d, expected %d", rec.Code, c.status)
+				}
+				if actual := rec.Header().Get("HX-Trigger"); actual != c.expectTrigger {
+					t.Errorf("HX-Trigger = %q, expected %q", actual, c.expectTrigger)
+				}
+			})
+		}
+	})
+
+	failureCases := []struct {
+		name   string
+		status int
+		detail any
+	}{
+		{name: "success status", status: http.StatusOK},
+		{name: "redirect status", status: http.StatusFound},
+		{name: "out of range", status: 600},
+		{name: "invalid detail", status: http.StatusBadRequest, detail: func() {}},
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range failureCases {
+			c := c
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				r := newTestRequest()
+				if err := RespondError(newUnwrittenWriter(t), r, c.status, c.detail); err == nil {
+					t.Error("expected RespondError to return an error")
+				}
+
+				if h := headers(r); len(h) > 0 {
+					t.Errorf("expected no headers to be set, got %v", h)
+				}
+			})
+		}
+	})
+}
+
+func TestRespond(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		var oob OOBSet
+		oob.Add("#cart-count", SwapInnerHTML, "3")
+		oob.Add("#cart-total", SwapInnerHTML, "$12")
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+			err := Respond(w, r, "<p>added</p>", &oob, func(r *http.Request) error {
+				return Trigger(r, "toast", "Item added")
+			})
+			if err != nil {
+				t.Errorf("Respond returned error: %v", err)
+			}
+		})
+
+		expectBody := `<p>added</p>` +
+			`<div hx-swap-oob="innerHTML:#cart-count">3</div>` +
+			`<div hx-swap-oob="innerHTML:#cart-total">$12</div>`
+		if actual := rec.Body.String(); actual != expectBody {
+			t.Errorf("body = %q, expected %q", actual, expectBody)
+		}
+		if actual := rec.Header().Get("Content-Type"); actual != "text/html; charset=utf-8" {
+			t.Errorf("Content-Type = %q, expected %q", actual, "text/html; charset=utf-8")
+		}
+		if actual := rec.Header().Get("HX-Trigger"); actual != `{"toast":"Item added"}` {
+			t.Errorf("HX-Trigger = %q, expected %q", actual, `{"toast":"Item added"}`)
+		}
+	})
+
+	t.Run("nil oob", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		if err := Respond(rec, newTestRequest(), "<p>a</p>", nil); err != nil {
+			t.Fatalf("Respond returned error: %v", err)
+		}
+
+		if actual := rec.Body.String(); actual != "<p>a</p>" {
+			t.Errorf("body = %q, expected %q", actual, "<p>a</p>")
+		}
+	})
+
+	t.Run("option error", func(t *testing.T) {
+		t.Parallel()
+
+		first := errors.New("abc")
+		err := Respond(newUnwrittenWriter(t), newTestRequest(), "<p>a</p>", nil,
+			func(*http.Request) error { return first },
+			func(*http.Request) error { return errors.New("def") },
+		)
+		if !errors.Is(err, first) {
+			t.Errorf("Respond() = %v, expected %v", err, first)
+		}
+	})
+}
+
+func TestOOBOnly(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		oob        func() *OOBSet
+		expectBody string
+	}{
+		{
+			name: "fragments",
+			oob: func() *OOBSet {
+				var oob OOBSet
+				oob.Add("#cart-count", SwapInnerHTML, "3")
+				oob.Add("#flash", SwapOuterHTML, "<p>saved</p>")
+				return &oob
+			},
+			expectBody: `<div hx-swap-oob="innerHTML:#cart-count">3</div>` +
+				`<div hx-swap-oob="outerHTML:#flash"><p>saved</p></div>`,
+		},
+		{name: "nil", oob: func() *OOBSet { return nil }, expectBody: ""},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+				Reswap(r, SwapOuterHTML)
+				if err := OOBOnly(w, r, c.oob()); err != nil {
+					t.Errorf("OOBOnly returned error: %v", err)
+				}
+			})
+
+			if actual := rec.Header().Get("HX-Reswap"); actual != "none" {
+				t.Errorf("HX-Reswap = %q, expected %q", actual, "none")
+			}
+			if actual := rec.Body.String(); actual != c.expectBody {
+				t.Errorf("body = %q, expected %q", actual, c.expectBody)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := serve(NewMiddleware(), r, func(w http.ResponseWriter, r *http.Request) {
+			err := Write(w, r, http.StatusCreated, "<li>item</li>", func(r *http.Request) error {
+				Retarget(r, "#items")
+				Reswap(r, SwapBeforeEnd)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Write returned error: %v", err)
+			}
+		})
+
+		if rec.Code != http.StatusCreated {
+			t.Errorf("status = %d, expected %d", rec.Code, http.StatusCreated)
+		}
+		if actual := rec.Body.String(); actual != "<li>item</li>" {
+			t.Errorf("body = %q, expected %q", actual, "<li>item</li>")
+		}
+		if actual := rec.Header().Get("Content-Type"); actual != "text/html; charset=utf-8" {
+			t.Errorf("Content-Type = %q, expected %q", actual, "text/html; charset=utf-8")
+		}
+		if actual := rec.Header().Get("HX-Retarget"); actual != "#items" {
+			t.Errorf("HX-Retarget = %q, expected %q", actual, "#items")
+		}
+		if actual := rec.Header().Get("HX-Reswap"); actual != "beforeend" {
+			t.Errorf("HX-Reswap = %q, expected %q", actual, "beforeend")
+		}
+	})
+
+	t.Run("option error", func(t *testing.T) {
+		t.Parallel()
+
+		var secondCalled bool
+		err := Write(newUnwrittenWriter(t), newTestRequest(), http.StatusOK, "<p>a</p>",
+			func(*http.Request) error { return errors.New("abc") },
+			func(*http.Request) error {
+				secondCalled = true
+				return nil
+			},
+		)
+		if err == nil {
+			t.Error("expected Write to return an error")
+		}
+		if secondCalled {
+			t.Error("expected options after the failing one not to be applied")
+		}
+	})
+}